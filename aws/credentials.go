@@ -1,27 +1,138 @@
 package aws
 
 import (
+	"context"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/inifile"
 	"gopkg.in/ini.v1"
 	"os"
+	"strings"
+	"time"
+)
+
+const (
+	sessionExpirationKeyName = "aws_session_expiration"
+
+	// credentialsFileMode is the permission of AWS credentials file, which contains secrets
+	credentialsFileMode = os.FileMode(0600)
+
+	// legacyExpirationLayout is the layout of time.Time.String() without the monotonic clock reading,
+	// which was written by old versions.
+	legacyExpirationLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
 )
 
 // SaveCredentials saves credentials to AWS credentials file.
 func SaveCredentials(profileName string, credentials sts.Credentials) error {
-	file := getCredentialsFilename()
-	c, err := ini.LooseLoad(file)
+	return inifile.Update(getCredentialsFilename(), credentialsFileMode, func(c *ini.File) error {
+		s := c.Section(profileName)
+		s.Key("aws_access_key_id").SetValue(*credentials.AccessKeyId)
+		s.Key("aws_secret_access_key").SetValue(*credentials.SecretAccessKey)
+		s.Key("aws_session_token").SetValue(*credentials.SessionToken)
+		s.Key(sessionExpirationKeyName).SetValue(formatExpiration(*credentials.Expiration))
+		return nil
+	})
+}
+
+// DeleteCredentials deletes credentials of the profile from AWS credentials file.
+// The profile is removed when no other keys remain.
+func DeleteCredentials(profileName string) error {
+	return inifile.Update(getCredentialsFilename(), credentialsFileMode, func(c *ini.File) error {
+		s, err := c.GetSection(profileName)
+		if err != nil {
+			// No credentials to delete.
+			return inifile.SkipSave
+		}
+
+		s.DeleteKey("aws_access_key_id")
+		s.DeleteKey("aws_secret_access_key")
+		s.DeleteKey("aws_session_token")
+		s.DeleteKey(sessionExpirationKeyName)
+		if len(s.Keys()) == 0 {
+			c.DeleteSection(profileName)
+		}
+		return nil
+	})
+}
+
+// LoadExpiration returns the expiration of the credentials of the profile in AWS credentials file.
+// ok is false when the profile has no expiration.
+func LoadExpiration(profileName string) (expiration time.Time, ok bool, err error) {
+	c, err := ini.LooseLoad(getCredentialsFilename())
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	s, err := c.GetSection(profileName)
+	if err != nil {
+		return time.Time{}, false, nil
+	}
+
+	value := s.Key(sessionExpirationKeyName).String()
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	expiration, err = ParseExpiration(value)
 	if err != nil {
-		return err
+		return time.Time{}, false, err
 	}
+	return expiration, true, nil
+}
+
+// MigrateExpirations rewrites aws_session_expiration written by old versions in AWS credentials file to RFC3339.
+// It returns the migrated profiles.
+func MigrateExpirations() ([]string, error) {
+	var migrated []string
+	err := inifile.Update(getCredentialsFilename(), credentialsFileMode, func(c *ini.File) error {
+		for _, s := range c.Sections() {
+			if !s.HasKey(sessionExpirationKeyName) {
+				continue
+			}
+
+			key := s.Key(sessionExpirationKeyName)
+			expiration, err := ParseExpiration(key.String())
+			if err != nil {
+				return err
+			}
 
-	s := c.Section(profileName)
-	s.Key("aws_access_key_id").SetValue(*credentials.AccessKeyId)
-	s.Key("aws_secret_access_key").SetValue(*credentials.SecretAccessKey)
-	s.Key("aws_session_token").SetValue(*credentials.SessionToken)
-	s.Key("aws_session_expiration").SetValue(credentials.Expiration.String())
+			value := formatExpiration(expiration)
+			if key.String() == value {
+				continue
+			}
+			key.SetValue(value)
+			migrated = append(migrated, s.Name())
+		}
 
-	return c.SaveTo(file)
+		if len(migrated) == 0 {
+			return inifile.SkipSave
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return migrated, nil
+}
+
+// ParseExpiration parses the value of aws_session_expiration in RFC3339 or the format of old versions
+func ParseExpiration(value string) (time.Time, error) {
+	if expiration, err := time.Parse(time.RFC3339, value); err == nil {
+		return expiration, nil
+	}
+
+	// Remove the monotonic clock reading, e.g. "m=+3600.000000001".
+	if i := strings.Index(value, " m="); i >= 0 {
+		value = value[:i]
+	}
+	return time.Parse(legacyExpirationLayout, value)
+}
+
+func formatExpiration(expiration time.Time) string {
+	return expiration.UTC().Format(time.RFC3339)
 }
 
 func getCredentialsFilename() string {
@@ -32,3 +143,17 @@ func getCredentialsFilename() string {
 	}
 	return file
 }
+
+// GetCallerIdentity sends a GetCallerIdentity request to AWS with the credentials
+func GetCallerIdentity(ctx context.Context, c sts.Credentials) (*sts.GetCallerIdentityOutput, error) {
+	svc := sts.New(newSessionWithCredentials(c))
+
+	return svc.GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+}
+
+// newSessionWithCredentials returns a session which signs requests with the credentials
+func newSessionWithCredentials(c sts.Credentials) *session.Session {
+	return session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials(*c.AccessKeyId, *c.SecretAccessKey, *c.SessionToken),
+	}))
+}
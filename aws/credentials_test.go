@@ -0,0 +1,91 @@
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExpiration(t *testing.T) {
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{
+			name:  "parses RFC3339",
+			value: "2024-01-02T03:04:05Z",
+		},
+		{
+			name:  "parses RFC3339 with time zone offset",
+			value: "2024-01-02T12:04:05+09:00",
+		},
+		{
+			name:  "parses time.Time.String() format",
+			value: "2024-01-02 03:04:05 +0000 UTC",
+		},
+		{
+			name:  "parses time.Time.String() format with monotonic clock reading",
+			value: "2024-01-02 12:04:05 +0900 JST m=+3600.000000001",
+		},
+		{
+			name:    "returns an error when the value is invalid",
+			value:   "tomorrow",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExpiration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseExpiration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.True(t, want.Equal(got), "got %s", got)
+			}
+		})
+	}
+}
+
+func TestMigrateExpirations(t *testing.T) {
+	// setup
+	file := filepath.Join(t.TempDir(), "credentials")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", file)
+	err := os.WriteFile(file, []byte(`[legacy]
+aws_session_expiration = 2024-01-02 12:04:05 +0900 JST
+
+[current]
+aws_session_expiration = 2024-01-02T03:04:05Z
+
+[static]
+aws_access_key_id = access-key-id
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// exercise
+	got, err := MigrateExpirations()
+
+	// verify
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"legacy"}, got)
+
+	for _, profile := range []string{"legacy", "current"} {
+		expiration, ok, err := LoadExpiration(profile)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "2024-01-02T03:04:05Z", formatExpiration(expiration))
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, string(data), "JST")
+}
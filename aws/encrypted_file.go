@@ -0,0 +1,132 @@
+package aws
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/defaults"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	saltSize = 16
+	keySize  = 32
+	// pbkdf2Iterations follows the recommendation of OWASP for PBKDF2-HMAC-SHA256.
+	pbkdf2Iterations = 600000
+)
+
+// EncryptedFileStore is CredentialStore of files encrypted by AES-256-GCM with a key derived from a passphrase.
+//
+// Each file consists of a random salt, a nonce and the sealed credentials.
+type EncryptedFileStore struct {
+	dir        string
+	passphrase string
+}
+
+// NewEncryptedFileStore returns EncryptedFileStore which saves files in ~/.config/assam/credentials
+func NewEncryptedFileStore(passphrase string) *EncryptedFileStore {
+	return &EncryptedFileStore{
+		dir:        filepath.Join(defaults.UserHomeDir(), ".config", "assam", "credentials"),
+		passphrase: passphrase,
+	}
+}
+
+// Save encrypts credentials of the profile and saves them to the file
+func (s *EncryptedFileStore) Save(profileName string, credentials sts.Credentials) error {
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltSize)
+	_, err = io.ReadFull(rand.Reader, salt)
+	if err != nil {
+		return err
+	}
+
+	aead, err := s.newAEAD(salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return err
+	}
+
+	data := append(salt, nonce...)
+	data = aead.Seal(data, nonce, plaintext, []byte(profileName))
+
+	err = os.MkdirAll(s.dir, os.FileMode(0700))
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(s.filename(profileName), data, os.FileMode(0600))
+}
+
+// Load decrypts credentials of the profile in the file
+func (s *EncryptedFileStore) Load(profileName string) (*sts.Credentials, error) {
+	data, err := os.ReadFile(s.filename(profileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < saltSize {
+		return nil, errors.New("encrypted credentials file is broken")
+	}
+	aead, err := s.newAEAD(data[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[saltSize:]
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("encrypted credentials file is broken")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(profileName))
+	if err != nil {
+		return nil, errors.New("failed to decrypt credentials: the passphrase may be wrong")
+	}
+
+	var credentials sts.Credentials
+	err = json.Unmarshal(plaintext, &credentials)
+	if err != nil {
+		return nil, err
+	}
+	return &credentials, nil
+}
+
+// Delete deletes the file of the profile
+func (s *EncryptedFileStore) Delete(profileName string) error {
+	err := os.Remove(s.filename(profileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *EncryptedFileStore) newAEAD(salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(s.passphrase), salt, pbkdf2Iterations, keySize, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *EncryptedFileStore) filename(profileName string) string {
+	return filepath.Join(s.dir, url.PathEscape(profileName)+".enc")
+}
@@ -0,0 +1,46 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptedFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store := &EncryptedFileStore{dir: dir, passphrase: "secret"}
+	expiration := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	credentials := sts.Credentials{
+		AccessKeyId:     aws.String("AKIAEXAMPLE"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+		Expiration:      &expiration,
+	}
+
+	got, err := store.Load("dev")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	err = store.Save("dev", credentials)
+	assert.NoError(t, err)
+
+	got, err = store.Load("dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "AKIAEXAMPLE", *got.AccessKeyId)
+	assert.Equal(t, "secret-access-key", *got.SecretAccessKey)
+	assert.Equal(t, "session-token", *got.SessionToken)
+	assert.True(t, expiration.Equal(*got.Expiration))
+
+	_, err = (&EncryptedFileStore{dir: dir, passphrase: "wrong"}).Load("dev")
+	assert.Error(t, err)
+
+	err = store.Delete("dev")
+	assert.NoError(t, err)
+
+	got, err = store.Load("dev")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
@@ -0,0 +1,24 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// GetAccountAlias returns the alias of the AWS account of the credentials. It returns empty if the account has no alias.
+func GetAccountAlias(ctx context.Context, c sts.Credentials) (string, error) {
+	svc := iam.New(newSessionWithCredentials(c))
+
+	res, err := svc.ListAccountAliasesWithContext(ctx, &iam.ListAccountAliasesInput{})
+	if err != nil {
+		return "", err
+	}
+
+	// An AWS account can have only one alias.
+	if len(res.AccountAliases) == 0 {
+		return "", nil
+	}
+	return *res.AccountAliases[0], nil
+}
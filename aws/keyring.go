@@ -0,0 +1,84 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const keyringService = "assam"
+
+// KeyringStore is CredentialStore of the Secret Service keyring such as GNOME Keyring and KWallet.
+// It uses secret-tool of libsecret to talk to the keyring over D-Bus.
+type KeyringStore struct {
+	command string
+}
+
+// NewKeyringStore returns KeyringStore
+func NewKeyringStore() *KeyringStore {
+	return &KeyringStore{command: "secret-tool"}
+}
+
+// Save saves credentials of the profile to the keyring
+func (s *KeyringStore) Save(profileName string, credentials sts.Credentials) error {
+	data, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+
+	label := fmt.Sprintf("--label=AWS credentials of profile %s", profileName)
+	_, err = s.run(data, append([]string{"store", label}, keyringAttributes(profileName)...)...)
+	return err
+}
+
+// Load returns credentials of the profile in the keyring
+func (s *KeyringStore) Load(profileName string) (*sts.Credentials, error) {
+	data, err := s.run(nil, append([]string{"lookup"}, keyringAttributes(profileName)...)...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(data) == 0 {
+		// secret-tool exits with 1 when no secret matches.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials sts.Credentials
+	err = json.Unmarshal(data, &credentials)
+	if err != nil {
+		return nil, err
+	}
+	return &credentials, nil
+}
+
+// Delete deletes credentials of the profile from the keyring
+func (s *KeyringStore) Delete(profileName string) error {
+	_, err := s.run(nil, append([]string{"clear"}, keyringAttributes(profileName)...)...)
+	return err
+}
+
+func (s *KeyringStore) run(stdin []byte, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(s.command, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return stdout.Bytes(), fmt.Errorf("%s %s: %w: %s", s.command, args[0], err, message)
+		}
+		return stdout.Bytes(), fmt.Errorf("%s %s: %w", s.command, args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func keyringAttributes(profileName string) []string {
+	return []string{"service", keyringService, "profile", profileName}
+}
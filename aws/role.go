@@ -0,0 +1,134 @@
+package aws
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Role is a pair of role ARN and principal ARN in the Role attribute of SAML response
+type Role struct {
+	RoleArn      string
+	PrincipalArn string
+}
+
+// AccountID returns the AWS account ID of the role
+func (r Role) AccountID() string {
+	// arn:partition:iam::account-id:role/role-name
+	s := strings.Split(r.RoleArn, ":")
+	if len(s) < 5 {
+		return ""
+	}
+	return s[4]
+}
+
+// Name returns the role name without the path
+func (r Role) Name() string {
+	return r.RoleArn[strings.LastIndex(r.RoleArn, "/")+1:]
+}
+
+// PathName returns the role name with the path, e.g. path/to/role-name
+func (r Role) PathName() string {
+	s := strings.SplitN(r.RoleArn, "/", 2)
+	if len(s) != 2 {
+		return ""
+	}
+	return s[1]
+}
+
+// Path returns the path of the role, e.g. /path/to/. It is / when the role has no path.
+func (r Role) Path() string {
+	pathName := r.PathName()
+	return "/" + pathName[:strings.LastIndex(pathName, "/")+1]
+}
+
+// RoleFilter selects roles. Glob patterns of path.Match are available except Regexp. Empty fields match any role.
+type RoleFilter struct {
+	// RoleArn matches the role ARN.
+	RoleArn string
+	// AccountID matches the AWS account ID.
+	AccountID string
+	// RoleName matches the role name with or without the path.
+	RoleName string
+	// Regexp matches the role ARN.
+	Regexp *regexp.Regexp
+}
+
+// IsEmpty reports whether the filter matches any role
+func (f RoleFilter) IsEmpty() bool {
+	return f.RoleArn == "" && f.AccountID == "" && f.RoleName == "" && f.Regexp == nil
+}
+
+// Match reports whether the role matches the filter
+func (f RoleFilter) Match(role Role) bool {
+	if f.RoleArn != "" && !matchGlob(f.RoleArn, role.RoleArn) {
+		return false
+	}
+	if f.AccountID != "" && !matchGlob(f.AccountID, role.AccountID()) {
+		return false
+	}
+	if f.RoleName != "" && !matchGlob(f.RoleName, role.Name()) && !matchGlob(f.RoleName, role.PathName()) {
+		return false
+	}
+	if f.Regexp != nil && !f.Regexp.MatchString(role.RoleArn) {
+		return false
+	}
+	return true
+}
+
+// String returns the description of the filter
+func (f RoleFilter) String() string {
+	if f.IsEmpty() {
+		return "any role"
+	}
+
+	var conditions []string
+	if f.RoleArn != "" {
+		conditions = append(conditions, "role ARN "+f.RoleArn)
+	}
+	if f.AccountID != "" {
+		conditions = append(conditions, "account "+f.AccountID)
+	}
+	if f.RoleName != "" {
+		conditions = append(conditions, "role "+f.RoleName)
+	}
+	if f.Regexp != nil {
+		conditions = append(conditions, "regexp "+f.Regexp.String())
+	}
+	return strings.Join(conditions, ", ")
+}
+
+// SelectRole returns the only role matching the filter.
+// The error lists candidates when no role or several roles match.
+func SelectRole(roles []Role, filter RoleFilter) (Role, error) {
+	var matched []Role
+	for _, role := range roles {
+		if filter.Match(role) {
+			matched = append(matched, role)
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		return Role{}, fmt.Errorf("no role matches %s. candidates are:%s", filter, formatRoleArns(roles))
+	case 1:
+		return matched[0], nil
+	default:
+		return Role{}, fmt.Errorf("%d roles match %s. please narrow down from:%s", len(matched), filter, formatRoleArns(matched))
+	}
+}
+
+func matchGlob(pattern string, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+func formatRoleArns(roles []Role) string {
+	var b strings.Builder
+	for _, role := range roles {
+		b.WriteString("\n  ")
+		b.WriteString(role.RoleArn)
+	}
+	return b.String()
+}
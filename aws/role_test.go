@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole(t *testing.T) {
+	role := Role{
+		RoleArn:      "arn:aws:iam::012345678901:role/path/TestRole",
+		PrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider",
+	}
+
+	assert.Equal(t, "012345678901", role.AccountID())
+	assert.Equal(t, "TestRole", role.Name())
+	assert.Equal(t, "path/TestRole", role.PathName())
+	assert.Equal(t, "/path/", role.Path())
+
+	role.RoleArn = "arn:aws:iam::012345678901:role/TestRole"
+	assert.Equal(t, "/", role.Path())
+}
+
+func TestSelectRole(t *testing.T) {
+	roles := []Role{
+		{
+			RoleArn:      "arn:aws:iam::012345678901:role/Admin",
+			PrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider",
+		},
+		{
+			RoleArn:      "arn:aws:iam::012345678901:role/path/ReadOnly",
+			PrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider",
+		},
+		{
+			RoleArn:      "arn:aws:iam::123456789012:role/Admin",
+			PrincipalArn: "arn:aws:iam::123456789012:saml-provider/TestProvider",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		filter  RoleFilter
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "selects by role ARN",
+			filter: RoleFilter{RoleArn: "arn:aws:iam::123456789012:role/Admin"},
+			want:   "arn:aws:iam::123456789012:role/Admin",
+		},
+		{
+			name:   "selects by account ID and role name",
+			filter: RoleFilter{AccountID: "0123*", RoleName: "Admin"},
+			want:   "arn:aws:iam::012345678901:role/Admin",
+		},
+		{
+			name:   "selects by role name without path",
+			filter: RoleFilter{RoleName: "ReadOnly"},
+			want:   "arn:aws:iam::012345678901:role/path/ReadOnly",
+		},
+		{
+			name:   "selects by role name with path",
+			filter: RoleFilter{RoleName: "path/*"},
+			want:   "arn:aws:iam::012345678901:role/path/ReadOnly",
+		},
+		{
+			name:   "selects by regexp",
+			filter: RoleFilter{Regexp: regexp.MustCompile(`::1234.*Admin$`)},
+			want:   "arn:aws:iam::123456789012:role/Admin",
+		},
+		{
+			name:    "returns an error when the selection is ambiguous",
+			filter:  RoleFilter{RoleName: "Admin"},
+			wantErr: true,
+		},
+		{
+			name:    "returns an error when no role matches",
+			filter:  RoleFilter{AccountID: "999999999999"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SelectRole(roles, tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SelectRole() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got.RoleArn)
+		})
+	}
+}
@@ -102,25 +102,33 @@ func ParseSAMLResponse(base64Response string) (*SAMLResponse, error) {
 	return &response, nil
 }
 
-// ExtractRoleArnAndPrincipalArn extracts role ARN and principal ARN from SAML response
-func ExtractRoleArnAndPrincipalArn(samlResponse SAMLResponse, roleName string) (string, string, error) {
+// ExtractRoles extracts all roles from SAML response
+func ExtractRoles(samlResponse SAMLResponse) ([]Role, error) {
+	var roles []Role
 	for _, attr := range samlResponse.Assertion.AttributeStatement.Attributes {
 		if attr.Name != roleAttributeName {
 			continue
 		}
 
 		for _, v := range attr.AttributeValues {
-			s := strings.Split(v.Value, ",")
-			roleArn := s[0]
-			principalArn := s[1]
-			if roleName != "" && strings.Split(roleArn, "/")[1] != roleName {
-				continue
+			s := strings.Split(strings.TrimSpace(v.Value), ",")
+			if len(s) != 2 {
+				return nil, fmt.Errorf("invalid role attribute value: %s", v.Value)
 			}
-			return roleArn, principalArn, nil
+
+			// Some IdPs put the principal ARN first.
+			if strings.Contains(s[0], ":saml-provider/") {
+				s[0], s[1] = s[1], s[0]
+			}
+			roles = append(roles, Role{RoleArn: s[0], PrincipalArn: s[1]})
 		}
 	}
 
-	return "", "", fmt.Errorf("no such attribute: %s", roleAttributeName)
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("no such attribute: %s", roleAttributeName)
+	}
+
+	return roles, nil
 }
 
 // AssumeRoleWithSAML sends a AssumeRoleWithSAML request to AWS and returns credentials
@@ -142,6 +150,40 @@ func AssumeRoleWithSAML(ctx context.Context, durationHours int, roleArn string,
 	return res.Credentials, nil
 }
 
+// AssumeRole sends a AssumeRole request to AWS with credentials of the source role and returns credentials.
+// The role session name is taken over from the source role to keep the user identity in CloudTrail.
+func AssumeRole(ctx context.Context, sourceCredentials sts.Credentials, roleArn string, externalID string) (*sts.Credentials, error) {
+	svc := sts.New(newSessionWithCredentials(sourceCredentials))
+
+	identity, err := svc.GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	input := sts.AssumeRoleInput{
+		// Role chaining limits the session to a maximum of one hour.
+		DurationSeconds: aws.Int64(60 * 60),
+		RoleArn:         aws.String(roleArn),
+		RoleSessionName: aws.String(SessionName(*identity.Arn)),
+	}
+	if externalID != "" {
+		input.ExternalId = aws.String(externalID)
+	}
+	res, err := svc.AssumeRoleWithContext(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Credentials, nil
+}
+
+// SessionName returns the role session name of the assumed role ARN
+//
+//	e.g. arn:aws:sts::012345678901:assumed-role/RoleName/SessionName
+func SessionName(assumedRoleArn string) string {
+	return assumedRoleArn[strings.LastIndex(assumedRoleArn, "/")+1:]
+}
+
 func deflate(src string) (*bytes.Buffer, error) {
 	b := new(bytes.Buffer)
 
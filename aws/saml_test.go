@@ -135,157 +135,119 @@ func TestParseSAMLResponse(t *testing.T) {
 	})
 }
 
-func TestExtractRoleArnAndPrincipalArn(t *testing.T) {
-	type args struct {
-		samlResponse SAMLResponse
-		roleName     string
-	}
+func TestExtractRoles(t *testing.T) {
 	tests := []struct {
-		name             string
-		args             args
-		wantRoleArn      string
-		wantPrincipalArn string
-		wantErr          bool
+		name         string
+		samlResponse SAMLResponse
+		want         []Role
+		wantErr      bool
 	}{
 		{
-			name: "extracts role ARN and principal ARN",
-			args: args{
-				samlResponse: SAMLResponse{
-					Assertion: Assertion{
-						AttributeStatement: AttributeStatement{
-							Attributes: []Attribute{
-								{
-									Name: "dummy",
-									AttributeValues: []AttributeValue{
-										{
-											Value: "dummy",
-										},
+			name: "extracts all roles",
+			samlResponse: SAMLResponse{
+				Assertion: Assertion{
+					AttributeStatement: AttributeStatement{
+						Attributes: []Attribute{
+							{
+								Name: roleAttributeName,
+								AttributeValues: []AttributeValue{
+									{
+										Value: "arn:aws:iam::012345678901:role/TestRole1,arn:aws:iam::012345678901:saml-provider/TestProvider",
 									},
-								},
-								{
-									Name: roleAttributeName,
-									AttributeValues: []AttributeValue{
-										{
-											Value: "arn:aws:iam::012345678901:role/TestRole,arn:aws:iam::012345678901:saml-provider/TestProvider",
-										},
+									{
+										Value: "arn:aws:iam::123456789012:role/path/TestRole2,arn:aws:iam::123456789012:saml-provider/TestProvider",
 									},
 								},
 							},
 						},
 					},
 				},
-				roleName: "",
 			},
-			wantRoleArn:      "arn:aws:iam::012345678901:role/TestRole",
-			wantPrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider",
+			want: []Role{
+				{
+					RoleArn:      "arn:aws:iam::012345678901:role/TestRole1",
+					PrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider",
+				},
+				{
+					RoleArn:      "arn:aws:iam::123456789012:role/path/TestRole2",
+					PrincipalArn: "arn:aws:iam::123456789012:saml-provider/TestProvider",
+				},
+			},
 		},
 		{
-			name: "returns first role when role attribute are multi and no roleName argument",
-			args: args{
-				samlResponse: SAMLResponse{
-					Assertion: Assertion{
-						AttributeStatement: AttributeStatement{
-							Attributes: []Attribute{
-								{
-									Name: "dummy",
-									AttributeValues: []AttributeValue{
-										{
-											Value: "dummy",
-										},
-									},
-								},
-								{
-									Name: roleAttributeName,
-									AttributeValues: []AttributeValue{
-										{
-											Value: "arn:aws:iam::012345678901:role/TestRole1,arn:aws:iam::012345678901:saml-provider/TestProvider1",
-										},
-										{
-											Value: "arn:aws:iam::012345678901:role/TestRole2,arn:aws:iam::012345678901:saml-provider/TestProvider2",
-										},
+			name: "extracts a role whose principal ARN comes first",
+			samlResponse: SAMLResponse{
+				Assertion: Assertion{
+					AttributeStatement: AttributeStatement{
+						Attributes: []Attribute{
+							{
+								Name: roleAttributeName,
+								AttributeValues: []AttributeValue{
+									{
+										Value: "arn:aws:iam::012345678901:saml-provider/TestProvider,arn:aws:iam::012345678901:role/TestRole",
 									},
 								},
 							},
 						},
 					},
 				},
-				roleName: "",
 			},
-			wantRoleArn:      "arn:aws:iam::012345678901:role/TestRole1",
-			wantPrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider1",
+			want: []Role{
+				{
+					RoleArn:      "arn:aws:iam::012345678901:role/TestRole",
+					PrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider",
+				},
+			},
 		},
 		{
-			name: "returns specify role when role attribute are multi and roleName argument",
-			args: args{
-				samlResponse: SAMLResponse{
-					Assertion: Assertion{
-						AttributeStatement: AttributeStatement{
-							Attributes: []Attribute{
-								{
-									Name: "dummy",
-									AttributeValues: []AttributeValue{
-										{
-											Value: "dummy",
-										},
-									},
-								},
-								{
-									Name: roleAttributeName,
-									AttributeValues: []AttributeValue{
-										{
-											Value: "arn:aws:iam::012345678901:role/TestRole1,arn:aws:iam::012345678901:saml-provider/TestProvider1",
-										},
-										{
-											Value: "arn:aws:iam::012345678901:role/TestRole2,arn:aws:iam::012345678901:saml-provider/TestProvider2",
-										},
+			name: "returns an error when role attribute value is invalid",
+			samlResponse: SAMLResponse{
+				Assertion: Assertion{
+					AttributeStatement: AttributeStatement{
+						Attributes: []Attribute{
+							{
+								Name: roleAttributeName,
+								AttributeValues: []AttributeValue{
+									{
+										Value: "arn:aws:iam::012345678901:role/TestRole",
 									},
 								},
 							},
 						},
 					},
 				},
-				roleName: "TestRole2",
 			},
-			wantRoleArn:      "arn:aws:iam::012345678901:role/TestRole2",
-			wantPrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider2",
+			wantErr: true,
 		},
 		{
 			name: "returns an error when role attribute does not exist",
-			args: args{
-				samlResponse: SAMLResponse{
-					Assertion: Assertion{
-						AttributeStatement: AttributeStatement{
-							Attributes: []Attribute{
-								{
-									Name: "dummy",
-									AttributeValues: []AttributeValue{
-										{
-											Value: "dummy",
-										},
+			samlResponse: SAMLResponse{
+				Assertion: Assertion{
+					AttributeStatement: AttributeStatement{
+						Attributes: []Attribute{
+							{
+								Name: "dummy",
+								AttributeValues: []AttributeValue{
+									{
+										Value: "dummy",
 									},
 								},
 							},
 						},
 					},
 				},
-				roleName: "",
 			},
 			wantErr: true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1, err := ExtractRoleArnAndPrincipalArn(tt.args.samlResponse, tt.args.roleName)
+			got, err := ExtractRoles(tt.samlResponse)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("ExtractRoleArnAndPrincipalArn() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("ExtractRoles() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.wantRoleArn {
-				t.Errorf("ExtractRoleArnAndPrincipalArn() got = %v, wantRoleArn %v", got, tt.wantRoleArn)
-			}
-			if got1 != tt.wantPrincipalArn {
-				t.Errorf("ExtractRoleArnAndPrincipalArn() got1 = %v, wantRoleArn %v", got1, tt.wantPrincipalArn)
-			}
+			assert.Equal(t, tt.want, got)
 		})
 	}
 }
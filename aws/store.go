@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"gopkg.in/ini.v1"
+)
+
+// CredentialStore saves credentials of profiles
+type CredentialStore interface {
+	// Save saves credentials of the profile, overwriting existing ones.
+	Save(profileName string, credentials sts.Credentials) error
+	// Load returns credentials of the profile. It returns nil without an error when no credentials are saved.
+	Load(profileName string) (*sts.Credentials, error)
+	// Delete deletes credentials of the profile. It does nothing when no credentials are saved.
+	Delete(profileName string) error
+}
+
+// FileStore is CredentialStore of AWS credentials file, which AWS SDKs and CLI read directly
+type FileStore struct{}
+
+// NewFileStore returns FileStore
+func NewFileStore() *FileStore {
+	return &FileStore{}
+}
+
+// Save saves credentials to AWS credentials file
+func (s *FileStore) Save(profileName string, credentials sts.Credentials) error {
+	return SaveCredentials(profileName, credentials)
+}
+
+// Load returns credentials of the profile in AWS credentials file
+func (s *FileStore) Load(profileName string) (*sts.Credentials, error) {
+	c, err := ini.LooseLoad(getCredentialsFilename())
+	if err != nil {
+		return nil, err
+	}
+
+	section, err := c.GetSection(profileName)
+	if err != nil || !section.HasKey("aws_access_key_id") {
+		return nil, nil
+	}
+
+	credentials := &sts.Credentials{
+		AccessKeyId:     aws.String(section.Key("aws_access_key_id").String()),
+		SecretAccessKey: aws.String(section.Key("aws_secret_access_key").String()),
+		SessionToken:    aws.String(section.Key("aws_session_token").String()),
+	}
+	if value := section.Key(sessionExpirationKeyName).String(); value != "" {
+		expiration, err := ParseExpiration(value)
+		if err != nil {
+			return nil, err
+		}
+		credentials.Expiration = &expiration
+	}
+
+	return credentials, nil
+}
+
+// Delete deletes credentials of the profile from AWS credentials file
+func (s *FileStore) Delete(profileName string) error {
+	return DeleteCredentials(profileName)
+}
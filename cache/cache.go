@@ -0,0 +1,112 @@
+// Package cache stores credentials to reuse them until they expire.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/defaults"
+)
+
+// Entry is cached credentials of a profile
+type Entry struct {
+	RoleArn     string
+	Credentials sts.Credentials
+}
+
+// Remaining returns the remaining lifetime of the credentials
+func (e *Entry) Remaining() time.Duration {
+	if e.Credentials.Expiration == nil {
+		return 0
+	}
+	return time.Until(*e.Credentials.Expiration)
+}
+
+// Load returns the cached entry of the profile if its credentials are valid for at least minRemaining.
+// It returns nil without an error when no such entry exists.
+func Load(profile string, minRemaining time.Duration) (*Entry, error) {
+	entry, err := Get(profile)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	if entry.Remaining() < minRemaining {
+		return nil, nil
+	}
+
+	return entry, nil
+}
+
+// Get returns the cached entry of the profile even if its credentials have expired.
+// It returns nil without an error when no entry exists.
+// Unreadable or corrupt entries are also treated as missing, because they are overwritten by the next Save.
+func Get(profile string) (*Entry, error) {
+	data, err := os.ReadFile(filename(profile))
+	if err != nil {
+		return nil, nil
+	}
+
+	var entry Entry
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		return nil, nil
+	}
+
+	return &entry, nil
+}
+
+// Save caches the entry of the profile
+func Save(profile string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	file := filename(profile)
+	err = os.MkdirAll(filepath.Dir(file), os.FileMode(0700))
+	if err != nil {
+		return err
+	}
+
+	return writeFile(file, data)
+}
+
+// Delete removes the cached entry of the profile
+func Delete(profile string) error {
+	err := os.Remove(filename(profile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// writeFile replaces the file by renaming a temporary file, so that parallel runs never read a half-written entry
+func writeFile(filename string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = tmp.Chmod(os.FileMode(0600))
+	if err == nil {
+		_, err = tmp.Write(data)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+func filename(profile string) string {
+	return filepath.Join(defaults.UserHomeDir(), ".config", "assam", "cache", url.PathEscape(profile)+".json")
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEntry(expiration time.Time) Entry {
+	return Entry{
+		RoleArn: "arn:aws:iam::012345678901:role/TestRole",
+		Credentials: sts.Credentials{
+			AccessKeyId:     aws.String("access-key-id"),
+			SecretAccessKey: aws.String("secret-access-key"),
+			SessionToken:    aws.String("session-token"),
+			Expiration:      aws.Time(expiration),
+		},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("returns the saved entry when it is valid long enough", func(t *testing.T) {
+		// setup
+		t.Setenv("HOME", t.TempDir())
+		entry := newEntry(time.Now().Add(time.Hour))
+		err := Save("test", entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// exercise
+		got, err := Load("test", 15*time.Minute)
+
+		// verify
+		assert.NoError(t, err)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, entry.RoleArn, got.RoleArn)
+			assert.Equal(t, *entry.Credentials.AccessKeyId, *got.Credentials.AccessKeyId)
+			assert.True(t, entry.Credentials.Expiration.Equal(*got.Credentials.Expiration))
+		}
+	})
+
+	t.Run("returns nil when the entry expires soon", func(t *testing.T) {
+		// setup
+		t.Setenv("HOME", t.TempDir())
+		err := Save("test", newEntry(time.Now().Add(10*time.Minute)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// exercise
+		got, err := Load("test", 15*time.Minute)
+
+		// verify
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("returns nil when the entry does not exist", func(t *testing.T) {
+		// setup
+		t.Setenv("HOME", t.TempDir())
+
+		// exercise
+		got, err := Load("test", 15*time.Minute)
+
+		// verify
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("returns nil after the entry is deleted", func(t *testing.T) {
+		// setup
+		t.Setenv("HOME", t.TempDir())
+		err := Save("test", newEntry(time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Delete("test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// exercise
+		got, err := Load("test", 15*time.Minute)
+
+		// verify
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("returns nil when the entry is corrupt", func(t *testing.T) {
+		// setup
+		t.Setenv("HOME", t.TempDir())
+		err := Save("test", newEntry(time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filename("test"), []byte(`{"RoleArn":`), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// exercise
+		got, err := Load("test", 15*time.Minute)
+
+		// verify
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/defaults"
+	"github.com/cybozu/assam/idp"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// agentSocketEnv is the environment variable of the socket of assam agent
+	agentSocketEnv       = "ASSAM_AGENT_SOCK"
+	agentCredentialsPath = "/credentials"
+)
+
+func newAgentCmd(opts *options) *cobra.Command {
+	var socket string
+	var shell string
+
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Serve credentials of profiles over a Unix socket",
+		Long: `Serve credentials of profiles over a Unix socket which only the user can access.
+Credentials are kept in memory and refreshed when they are about to expire,
+and Chrome is kept running to authenticate without waiting for it to start.
+It prints a shell command to set ` + agentSocketEnv + `, with which credential-process and exec get credentials from the agent.
+The agent never asks the user to pick a role, so the role must be specified when several roles are available.
+
+  assam agent > ~/.config/assam/agent.env &
+  source ~/.config/assam/agent.env`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			shell, err := resolveShell(shell)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			listener, err := listenAgentSocket(socket)
+			if err != nil {
+				return err
+			}
+
+			err = printEnvironmentVariables(cmd.OutOrStdout(), shell, []environmentVariable{
+				{name: agentSocketEnv, value: socket},
+			})
+			if err != nil {
+				listener.Close()
+				return err
+			}
+
+			pool := idp.NewBrowserPool(ctx)
+			defer pool.Close()
+
+			return serveHTTP(ctx, listener, newAgent(ctx, *opts, pool))
+		},
+	}
+	cmd.Flags().StringVar(&socket, "socket", filepath.Join(defaults.UserHomeDir(), ".config", "assam", "agent.sock"), "path of the Unix socket")
+	cmd.Flags().StringVar(&shell, "shell", "", fmt.Sprintf("shell syntax (%s). Detected from $SHELL by default", strings.Join(shells, ", ")))
+
+	return cmd
+}
+
+// listenAgentSocket listens on the Unix socket which only the user can access
+func listenAgentSocket(socket string) (net.Listener, error) {
+	err := os.MkdirAll(filepath.Dir(socket), os.FileMode(0700))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(socket); err == nil {
+		conn, err := net.Dial("unix", socket)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("assam agent is already running on %s", socket)
+		}
+		// Remove the socket left by the agent which did not exit normally.
+		err = os.Remove(socket)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := listenUnix(socket)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.Chmod(socket, os.FileMode(0600))
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// agent serves credentials of profiles requested by thin clients
+type agent struct {
+	// ctx is the context of the agent, which refreshes credentials with Chrome in the pool.
+	ctx  context.Context
+	opts options
+
+	mu         sync.Mutex
+	refreshers map[string]*refresher
+}
+
+func newAgent(ctx context.Context, opts options, pool *idp.BrowserPool) *agent {
+	if pool != nil {
+		ctx = idp.WithBrowserPool(ctx, pool)
+	}
+	// The agent usually runs in background, and clients must specify the role when several roles are available.
+	opts.noPrompt = true
+	opts.memoryOnly = true
+	return &agent{
+		ctx:        ctx,
+		opts:       opts,
+		refreshers: map[string]*refresher{},
+	}
+}
+
+func (a *agent) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != agentCredentialsPath {
+		http.NotFound(w, req)
+		return
+	}
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := req.URL.Query()
+	r, err := a.refresher(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	credentials, err := r.Credentials(req.Context())
+	var roleErr roleSelectionError
+	if errors.As(err, &roleErr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(newCredentialProcessOutput(*credentials))
+}
+
+// refresher returns the refresher of the profile and role options in the query
+func (a *agent) refresher(query url.Values) (*refresher, error) {
+	force := query.Get("force") == "true"
+	query.Del("force")
+	key := query.Encode()
+
+	opts := a.opts
+	opts.profile = query.Get("profile")
+	opts.roleName = query.Get("role")
+	opts.roleArn = query.Get("role-arn")
+	opts.accountID = query.Get("account")
+	opts.roleRegexp = query.Get("role-regexp")
+	opts.force = force
+	if value := query.Get("min-remaining"); value != "" {
+		var err error
+		opts.minRemaining, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.profile == "" {
+		return nil, errors.New("profile is required")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	r, ok := a.refreshers[key]
+	if !ok || force {
+		r = newRefresher(a.ctx, opts)
+		a.refreshers[key] = r
+	}
+	return r, nil
+}
+
+// agentQuery returns the query of the request to the agent for the profile and role options
+func agentQuery(opts *options) url.Values {
+	query := url.Values{}
+	query.Set("profile", opts.profile)
+	query.Set("min-remaining", opts.minRemaining.String())
+	for name, value := range map[string]string{
+		"role":        opts.roleName,
+		"role-arn":    opts.roleArn,
+		"account":     opts.accountID,
+		"role-regexp": opts.roleRegexp,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if opts.force {
+		query.Set("force", strconv.FormatBool(opts.force))
+	}
+	return query
+}
+
+// getCredentials returns credentials from the agent if ASSAM_AGENT_SOCK is set, otherwise it assumes the role
+func getCredentials(ctx context.Context, opts *options) (*sts.Credentials, error) {
+	if socket := os.Getenv(agentSocketEnv); socket != "" {
+		return requestAgent(ctx, socket, opts)
+	}
+	return assumeRole(ctx, opts)
+}
+
+// requestAgent gets credentials from the agent listening on the socket
+func requestAgent(ctx context.Context, socket string, opts *options) (*sts.Credentials, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://assam-agent"+agentCredentialsPath+"?"+agentQuery(opts).Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to assam agent on %s", socket)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("assam agent: %s", strings.TrimSpace(string(message)))
+	}
+
+	var output credentialProcessOutput
+	err = json.NewDecoder(resp.Body).Decode(&output)
+	if err != nil {
+		return nil, err
+	}
+
+	expiration, err := time.Parse(time.RFC3339, output.Expiration)
+	if err != nil {
+		return nil, err
+	}
+	return &sts.Credentials{
+		AccessKeyId:     aws.String(output.AccessKeyID),
+		SecretAccessKey: aws.String(output.SecretAccessKey),
+		SessionToken:    aws.String(output.SessionToken),
+		Expiration:      aws.Time(expiration),
+	}, nil
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestAgent(t *testing.T) {
+	// setup
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := listenAgentSocket(socket)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(socket)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	_, err = listenAgentSocket(socket)
+	assert.Error(t, err, "an agent is already running")
+
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	opts := &options{profile: "dev", roleName: "Admin", minRemaining: 15 * time.Minute}
+	a := newAgent(context.Background(), options{}, nil)
+	a.refreshers[agentQuery(opts).Encode()] = &refresher{
+		opts: *opts,
+		credentials: &sts.Credentials{
+			AccessKeyId:     aws.String("access-key-id"),
+			SecretAccessKey: aws.String("secret-access-key"),
+			SessionToken:    aws.String("session-token"),
+			Expiration:      &expiration,
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- serveHTTP(ctx, listener, a)
+	}()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+
+	// exercise
+	got, err := requestAgent(ctx, socket, opts)
+
+	// verify
+	assert.NoError(t, err)
+	assert.Equal(t, &sts.Credentials{
+		AccessKeyId:     aws.String("access-key-id"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+		Expiration:      &expiration,
+	}, got)
+
+	_, err = requestAgent(ctx, socket, &options{})
+	assert.EqualError(t, err, "assam agent: profile is required")
+}
+
+func TestAgentKeepsCredentialsInMemory(t *testing.T) {
+	// setup
+	roleArn := "arn:aws:iam::012345678901:role/Admin"
+	setupRefreshedProfile(t, roleArn)
+	fake := newFakeIdP(t, roleArn)
+	a := newAgent(context.Background(), options{minRemaining: 15 * time.Minute}, nil)
+	r, err := a.refresher(url.Values{"profile": {"dev"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// exercise
+	got, err := r.Credentials(context.Background())
+
+	// verify
+	assert.NoError(t, err)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, "access-key-id-of-"+roleArn, *got.AccessKeyId)
+	}
+	assert.Equal(t, roleArn, r.opts.roleArn)
+	assert.Equal(t, 1, fake.authentications)
+	entry, err := cache.Get("dev")
+	assert.NoError(t, err)
+	assert.Nil(t, entry)
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/cache"
+	"github.com/cybozu/assam/config"
+	"github.com/cybozu/assam/idp"
+	"github.com/pkg/errors"
+)
+
+// Functions which access the IdP and AWS. Tests replace them.
+var (
+	authenticateFunc       = authenticate
+	assumeRoleWithSAMLFunc = aws.AssumeRoleWithSAML
+)
+
+// assumeRole returns credentials of the role.
+// It reuses cached credentials if they are valid for long enough, otherwise it authenticates with the IdP of the profile.
+func assumeRole(ctx context.Context, opts *options) (*sts.Credentials, error) {
+	entry, err := assumeRoleEntry(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &entry.Credentials, nil
+}
+
+// assumeRoleEntry is assumeRole which also returns the role selected from the SAML response
+func assumeRoleEntry(ctx context.Context, opts *options) (*cache.Entry, error) {
+	cfg, err := loadConfig(opts.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newRoleFilter(opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := loadCache(opts, opts.profile, cfg, filter)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		return entry, nil
+	}
+
+	base64Response, response, err := authenticateFunc(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return assumeRoleWithSAML(ctx, opts, opts.profile, cfg, filter, *response, base64Response)
+}
+
+func loadConfig(profile string) (config.Config, error) {
+	cfg, err := config.NewConfig(profile)
+	if err != nil {
+		return cfg, errors.Wrap(err, "please run `assam --configure` at the first time")
+	}
+	return cfg, nil
+}
+
+// loadCache returns cached credentials of the role if they are reusable
+func loadCache(opts *options, profile string, cfg config.Config, filter aws.RoleFilter) (*cache.Entry, error) {
+	if opts.force {
+		return nil, nil
+	}
+
+	entry, err := cache.Load(profile, opts.minRemaining)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil || !filter.Match(aws.Role{RoleArn: entry.RoleArn}) {
+		return nil, nil
+	}
+
+	if !usesFileStore(cfg) {
+		store, err := newCredentialStore(cfg)
+		if err != nil {
+			return nil, err
+		}
+		credentials, err := store.Load(profile)
+		if err != nil {
+			return nil, err
+		}
+		if credentials == nil {
+			return nil, nil
+		}
+		entry.Credentials = *credentials
+	}
+
+	opts.logf("Reused cached credentials of %s (expires at %s)", entry.RoleArn, entry.Credentials.Expiration.Local())
+	return entry, nil
+}
+
+// authenticate authenticates with the IdP and returns the base64 encoded SAML response and the parsed one
+func authenticate(ctx context.Context, cfg config.Config) (string, *aws.SAMLResponse, error) {
+	provider, err := idp.NewProvider(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	base64Response, err := provider.Authenticate(ctx, cfg.ChromeUserDataDir)
+	if err != nil {
+		return "", nil, err
+	}
+
+	response, err := aws.ParseSAMLResponse(base64Response)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return base64Response, response, nil
+}
+
+// assumeRoleWithSAML assumes the role selected from SAML response, follows the role chain and caches the credentials
+// unless opts.memoryOnly is set. It returns the credentials with the selected role.
+func assumeRoleWithSAML(ctx context.Context, opts *options, profile string, cfg config.Config, filter aws.RoleFilter, response aws.SAMLResponse, base64Response string) (*cache.Entry, error) {
+	role, err := selectRole(response, filter, opts.noPrompt)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := assumeRoleWithSAMLFunc(ctx, cfg.DefaultSessionDurationHours, role.RoleArn, role.PrincipalArn, base64Response)
+	if err != nil {
+		return nil, err
+	}
+	opts.logf("Assumed %s (expires at %s)", role.RoleArn, credentials.Expiration.Local())
+
+	assumedRoleArn := role.RoleArn
+	for _, chainedRole := range cfg.RoleChain {
+		credentials, err = aws.AssumeRole(ctx, *credentials, chainedRole.RoleArn, chainedRole.ExternalID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to assume %s", chainedRole.RoleArn)
+		}
+		opts.logf("Assumed %s (expires at %s)", chainedRole.RoleArn, credentials.Expiration.Local())
+		assumedRoleArn = chainedRole.RoleArn
+	}
+
+	if opts.fetchAccountAlias {
+		fetchAccountAlias(ctx, opts, aws.Role{RoleArn: assumedRoleArn}.AccountID(), *credentials)
+	}
+
+	entry := &cache.Entry{RoleArn: role.RoleArn, Credentials: *credentials}
+	if opts.memoryOnly {
+		return entry, nil
+	}
+
+	err = saveCache(profile, cfg, role.RoleArn, *credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// saveCache caches credentials of the role.
+// Secrets are saved to the credential store of the profile unless it is AWS credentials file,
+// and only the expiration is cached in plaintext.
+func saveCache(profile string, cfg config.Config, roleArn string, credentials sts.Credentials) error {
+	entry := cache.Entry{RoleArn: roleArn, Credentials: credentials}
+	if !usesFileStore(cfg) {
+		store, err := newCredentialStore(cfg)
+		if err != nil {
+			return err
+		}
+		err = store.Save(profile, credentials)
+		if err != nil {
+			return err
+		}
+		entry.Credentials = sts.Credentials{Expiration: credentials.Expiration}
+	}
+
+	return cache.Save(profile, entry)
+}
+
+// fetchAccountAlias saves the alias of the AWS account of the credentials.
+// Failures are only reported because the role may not be allowed to call iam:ListAccountAliases.
+func fetchAccountAlias(ctx context.Context, opts *options, accountID string, credentials sts.Credentials) {
+	alias, err := aws.GetAccountAlias(ctx, credentials)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to get the alias of account %s: %s\n", accountID, err)
+		return
+	}
+	if alias == "" {
+		return
+	}
+
+	err = config.SaveAccountAlias(accountID, alias)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save the alias of account %s: %s\n", accountID, err)
+		return
+	}
+	opts.logf("Saved alias %s of account %s", alias, accountID)
+}
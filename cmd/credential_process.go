@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/spf13/cobra"
+)
+
+// credentialProcessOutput is the output format of credential_process
+// ref: https://docs.aws.amazon.com/cli/latest/userguide/cli-configure-sourcing-external.html
+type credentialProcessOutput struct {
+	Version         int
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      string
+}
+
+func newCredentialProcessCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "credential-process",
+		Short: "Print credentials in the credential_process format of AWS SDKs and CLI",
+		Long: `Print credentials in the credential_process format of AWS SDKs and CLI.
+Credentials are not saved to the AWS credentials file.
+They are got from assam agent when ` + agentSocketEnv + ` is set.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			credentials, err := getCredentials(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			return printCredentialProcessOutput(cmd, *credentials)
+		},
+	}
+}
+
+func printCredentialProcessOutput(cmd *cobra.Command, credentials sts.Credentials) error {
+	return json.NewEncoder(cmd.OutOrStdout()).Encode(newCredentialProcessOutput(credentials))
+}
+
+func newCredentialProcessOutput(credentials sts.Credentials) credentialProcessOutput {
+	return credentialProcessOutput{
+		Version:         1,
+		AccessKeyID:     *credentials.AccessKeyId,
+		SecretAccessKey: *credentials.SecretAccessKey,
+		SessionToken:    *credentials.SessionToken,
+		Expiration:      credentials.Expiration.UTC().Format(time.RFC3339),
+	}
+}
+
+// credentialProcessCommand returns the credential_process setting which runs this executable for the profile
+func credentialProcessCommand(profile string) (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	return formatCredentialProcessCommand(executable, profile), nil
+}
+
+// formatCredentialProcessCommand returns the command line of credential-process.
+// The executable is quoted if it contains spaces, because AWS SDKs and CLI split the command line by spaces.
+func formatCredentialProcessCommand(executable string, profile string) string {
+	if strings.ContainsAny(executable, " \t") {
+		// Backslashes are kept as is, because they separate directories on Windows.
+		executable = `"` + executable + `"`
+	}
+
+	return fmt.Sprintf("%s credential-process --profile %s", executable, profile)
+}
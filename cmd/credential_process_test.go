@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCredentialProcessOutput(t *testing.T) {
+	// setup
+	jst := time.FixedZone("JST", 9*60*60)
+	credentials := sts.Credentials{
+		AccessKeyId:     aws.String("access-key-id"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+		Expiration:      aws.Time(time.Date(2024, 1, 2, 12, 4, 5, 0, jst)),
+	}
+
+	// exercise
+	got, err := json.Marshal(newCredentialProcessOutput(credentials))
+
+	// verify
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"Version": 1,
+		"AccessKeyId": "access-key-id",
+		"SecretAccessKey": "secret-access-key",
+		"SessionToken": "session-token",
+		"Expiration": "2024-01-02T03:04:05Z"
+	}`, string(got))
+}
+
+func TestFormatCredentialProcessCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		executable string
+		want       string
+	}{
+		{name: "path without spaces", executable: "/usr/local/bin/assam", want: "/usr/local/bin/assam credential-process --profile dev"},
+		{name: "path with spaces", executable: "/Users/John Doe/bin/assam", want: `"/Users/John Doe/bin/assam" credential-process --profile dev`},
+		{name: "Windows path with spaces", executable: `C:\Program Files\assam\assam.exe`, want: `"C:\Program Files\assam\assam.exe" credential-process --profile dev`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatCredentialProcessCommand(tt.executable, "dev"))
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cybozu/assam/config"
+	"github.com/cybozu/assam/idp"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newDaemonCmd(opts *options) *cobra.Command {
+	var profiles []string
+	var group string
+	var interval time.Duration
+	var headlessTimeout time.Duration
+	var interactionTimeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Refresh credentials of profiles in background before they expire",
+		Long: `Refresh credentials of profiles when they expire within --min-remaining, until it is interrupted.
+Profiles are specified by --profiles or --group, and all profiles configured by assam by default.
+Authentication runs in headless Chrome first, which needs no interaction while the session of the IdP
+in the Chrome user data directory is valid. Chrome is opened in a window only when the IdP requires interaction,
+and closed after --interaction-timeout so that other profiles are refreshed.
+Profiles need assam_role_arn or assam_role_name, because the daemon never asks the user to pick a role.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			profiles, err := groupProfiles(profiles, group)
+			if err != nil {
+				return err
+			}
+			if len(profiles) == 0 {
+				profiles, err = config.Profiles()
+				if err != nil {
+					return err
+				}
+			}
+			profiles = profilesWithRole(opts, profiles)
+			if len(profiles) == 0 {
+				return errors.New("no profiles are configured by assam with assam_role_arn or assam_role_name")
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			ctx = idp.WithHeadless(ctx, headlessTimeout, interactionTimeout)
+			daemonOpts := *opts
+			daemonOpts.noPrompt = true
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				refreshProfiles(ctx, &daemonOpts, profiles)
+				// --force only applies to the first refresh.
+				daemonOpts.force = false
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+				}
+			}
+		},
+	}
+	cmd.Flags().StringSliceVar(&profiles, "profiles", nil, "comma separated AWS profiles (default: all profiles configured by assam)")
+	cmd.Flags().StringVar(&group, "group", "", "profile group defined as [assam-group NAME] in AWS config file")
+	cmd.Flags().DurationVar(&interval, "interval", time.Minute, "interval to check the expiration of credentials")
+	cmd.Flags().DurationVar(&headlessTimeout, "headless-timeout", 30*time.Second, "time to wait for headless authentication before opening Chrome in a window")
+	cmd.Flags().DurationVar(&interactionTimeout, "interaction-timeout", 5*time.Minute, "time to wait for authentication in Chrome opened in a window")
+
+	return cmd
+}
+
+// refreshProfiles saves credentials of the profiles which expire within --min-remaining.
+// Errors are only reported, so that the daemon retries at the next interval.
+func refreshProfiles(ctx context.Context, opts *options, profiles []string) {
+	var due []string
+	var configs []config.Config
+	for _, profile := range profiles {
+		status, err := getProfileStatus(profile, time.Now())
+		if err != nil {
+			reportRefreshError(profile, err)
+			continue
+		}
+		if !opts.force && status.Expiration != nil && status.Expiration.Sub(time.Now()) >= opts.minRemaining {
+			continue
+		}
+
+		cfg, err := loadConfig(profile)
+		if err != nil {
+			reportRefreshError(profile, err)
+			continue
+		}
+		due = append(due, profile)
+		configs = append(configs, cfg)
+	}
+
+	for _, group := range groupByIdentityProvider(due, configs) {
+		err := login(ctx, opts, group)
+		var failures profileErrors
+		if err != nil && !errors.As(err, &failures) {
+			reportRefreshError(strings.Join(group, ","), err)
+			continue
+		}
+		for _, failure := range failures {
+			reportRefreshError(failure.profile, failure.err)
+		}
+
+		var refreshed []string
+		for _, profile := range group {
+			if !failures.failed(profile) {
+				refreshed = append(refreshed, profile)
+			}
+		}
+		if len(refreshed) != 0 {
+			opts.logf("Refreshed credentials of %s", strings.Join(refreshed, ","))
+		}
+	}
+}
+
+// profilesWithRole returns the profiles whose role is specified, and reports the others,
+// because the daemon cannot ask the user to pick a role in background
+func profilesWithRole(opts *options, profiles []string) []string {
+	var selected []string
+	for _, profile := range profiles {
+		cfg, err := loadConfig(profile)
+		if err != nil {
+			reportRefreshError(profile, err)
+			continue
+		}
+		filter, err := newRoleFilter(opts, cfg)
+		if err != nil {
+			reportRefreshError(profile, err)
+			continue
+		}
+		if filter.IsEmpty() {
+			reportRefreshError(profile, errors.New("assam_role_arn or assam_role_name of the profile is required"))
+			continue
+		}
+		selected = append(selected, profile)
+	}
+	return selected
+}
+
+// groupByIdentityProvider splits profiles into groups of the same IdP settings, each of which needs a single authentication
+func groupByIdentityProvider(profiles []string, configs []config.Config) [][]string {
+	var groups [][]string
+	var groupConfigs []config.Config
+	for i, profile := range profiles {
+		found := false
+		for j := range groups {
+			if groupConfigs[j].SameIdentityProvider(configs[i]) {
+				groups[j] = append(groups[j], profile)
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, []string{profile})
+			groupConfigs = append(groupConfigs, configs[i])
+		}
+	}
+	return groups
+}
+
+func reportRefreshError(profile string, err error) {
+	fmt.Fprintf(os.Stderr, "%s failed to refresh credentials of %s: %s\n", time.Now().Format(time.RFC3339), profile, err)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybozu/assam/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupByIdentityProvider(t *testing.T) {
+	azure := config.Config{IDP: config.IDPAzure, AzureTenantID: "tenant", AppIDURI: "app", ChromeUserDataDir: "dir"}
+	otherTenant := config.Config{IDP: config.IDPAzure, AzureTenantID: "other", AppIDURI: "app", ChromeUserDataDir: "dir"}
+	okta := config.Config{IDP: config.IDPOkta, OktaOrgURL: "https://example.okta.com", OktaAppEmbedPath: "/home/amazon_aws/0oa1b2c3d4/272", ChromeUserDataDir: "dir"}
+
+	tests := []struct {
+		name     string
+		profiles []string
+		configs  []config.Config
+		want     [][]string
+	}{
+		{
+			name: "returns no groups for no profiles",
+		},
+		{
+			name:     "groups profiles of the same IdP settings in order",
+			profiles: []string{"dev", "okta", "stg", "other", "prod"},
+			configs: []config.Config{
+				withRoleArn(azure, "arn:aws:iam::111122223333:role/Dev"),
+				okta,
+				withRoleArn(azure, "arn:aws:iam::111122223333:role/Stg"),
+				otherTenant,
+				withRoleArn(azure, "arn:aws:iam::111122223333:role/Prod"),
+			},
+			want: [][]string{{"dev", "stg", "prod"}, {"okta"}, {"other"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := groupByIdentityProvider(tt.profiles, tt.configs)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProfilesWithRole(t *testing.T) {
+	// setup
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	err := os.WriteFile(filepath.Join(dir, "config"), []byte(`[profile dev]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+assam_role_name = Admin
+
+[profile any]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("skips profiles without role", func(t *testing.T) {
+		got := profilesWithRole(&options{}, []string{"dev", "any", "missing"})
+
+		assert.Equal(t, []string{"dev"}, got)
+	})
+
+	t.Run("keeps profiles whose role is given by the flags", func(t *testing.T) {
+		got := profilesWithRole(&options{roleName: "Admin"}, []string{"dev", "any"})
+
+		assert.Equal(t, []string{"dev", "any"}, got)
+	})
+}
+
+func withRoleArn(cfg config.Config, roleArn string) config.Config {
+	cfg.RoleArn = roleArn
+	return cfg
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Shells supported by env subcommand.
+const (
+	shellBash       = "bash"
+	shellZsh        = "zsh"
+	shellFish       = "fish"
+	shellPowerShell = "powershell"
+)
+
+var shells = []string{shellBash, shellZsh, shellFish, shellPowerShell}
+
+func newEnvCmd(opts *options) *cobra.Command {
+	var shell string
+
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Print credentials as shell commands to set environment variables",
+		Long: `Print credentials as shell commands to set environment variables.
+Credentials are not saved to the AWS credentials file. With the file credential store, they are cached
+in plaintext in ~/.config/assam/cache until they expire.
+
+  eval "$(assam env -p dev)"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			shell, err := resolveShell(shell)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			credentials, err := assumeRole(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			return printEnvironmentVariables(cmd.OutOrStdout(), shell, credentialsVariables(*credentials))
+		},
+	}
+	cmd.Flags().StringVar(&shell, "shell", "", fmt.Sprintf("shell syntax (%s). Detected from $SHELL by default", strings.Join(shells, ", ")))
+
+	return cmd
+}
+
+// resolveShell validates the shell specified by --shell, or detects it when not specified
+func resolveShell(shell string) (string, error) {
+	if shell == "" {
+		shell = detectShell()
+	}
+	if !isSupportedShell(shell) {
+		return "", fmt.Errorf("shell must be one of %s: %s", strings.Join(shells, ", "), shell)
+	}
+	return shell, nil
+}
+
+func isSupportedShell(shell string) bool {
+	for _, s := range shells {
+		if shell == s {
+			return true
+		}
+	}
+	return false
+}
+
+// detectShell guesses the shell of the user from environment variables
+func detectShell() string {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	switch shell {
+	case shellFish, shellZsh, shellBash:
+		return shell
+	case "pwsh":
+		return shellPowerShell
+	}
+
+	if runtime.GOOS == "windows" && os.Getenv("SHELL") == "" {
+		return shellPowerShell
+	}
+
+	return shellBash
+}
+
+func printEnvironmentVariables(w io.Writer, shell string, variables []environmentVariable) error {
+	for _, v := range variables {
+		var line string
+		switch shell {
+		case shellFish:
+			line = fmt.Sprintf("set -gx %s %s;", v.name, quoteFish(v.value))
+		case shellPowerShell:
+			line = fmt.Sprintf("$Env:%s = %s", v.name, quotePowerShell(v.value))
+		default:
+			line = fmt.Sprintf("export %s=%s", v.name, quotePOSIX(v.value))
+		}
+
+		_, err := fmt.Fprintln(w, line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func quotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func quoteFish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintEnvironmentVariables(t *testing.T) {
+	variables := []environmentVariable{
+		{name: "AWS_ACCESS_KEY_ID", value: "access-key-id"},
+		{name: "QUOTED", value: `it's \ok`},
+	}
+
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{
+			shell: shellBash,
+			want:  "export AWS_ACCESS_KEY_ID='access-key-id'\nexport QUOTED='it'\\''s \\ok'\n",
+		},
+		{
+			shell: shellZsh,
+			want:  "export AWS_ACCESS_KEY_ID='access-key-id'\nexport QUOTED='it'\\''s \\ok'\n",
+		},
+		{
+			shell: shellFish,
+			want:  "set -gx AWS_ACCESS_KEY_ID 'access-key-id';\nset -gx QUOTED 'it\\'s \\\\ok';\n",
+		},
+		{
+			shell: shellPowerShell,
+			want:  "$Env:AWS_ACCESS_KEY_ID = 'access-key-id'\n$Env:QUOTED = 'it''s \\ok'\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			err := printEnvironmentVariables(buf, tt.shell, variables)
+			if err != nil {
+				t.Errorf("printEnvironmentVariables() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestDetectShell(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{shell: "/usr/local/bin/fish", want: shellFish},
+		{shell: "/bin/zsh", want: shellZsh},
+		{shell: "/usr/bin/pwsh", want: shellPowerShell},
+		{shell: "/bin/sh", want: shellBash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			t.Setenv("SHELL", tt.shell)
+			assert.Equal(t, tt.want, detectShell())
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// exitCodeError makes assam exit with the code without printing an error
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// environmentVariable is a pair of environment variable name and value
+type environmentVariable struct {
+	name  string
+	value string
+}
+
+func newExecCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec [flags] -- command [args...]",
+		Short: "Run a command with credentials in its environment",
+		Long: `Run a command with credentials in its environment variables.
+Credentials are not saved to the AWS credentials file. With the file credential store, they are cached
+in plaintext in ~/.config/assam/cache until they expire.
+They are got from assam agent when ` + agentSocketEnv + ` is set.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Signals cancel authentication until the command starts, and then they are forwarded to the command.
+			var mu sync.Mutex
+			var process *os.Process
+			notifySignal(func(sig os.Signal) {
+				mu.Lock()
+				defer mu.Unlock()
+				if process != nil {
+					_ = process.Signal(sig)
+					return
+				}
+				cancel()
+			})
+
+			credentials, err := getCredentials(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			c := exec.Command(args[0], args[1:]...)
+			c.Env = credentialsEnviron(os.Environ(), *credentials)
+			c.Stdin = os.Stdin
+			c.Stdout = os.Stdout
+			c.Stderr = os.Stderr
+
+			mu.Lock()
+			err = c.Start()
+			process = c.Process
+			mu.Unlock()
+			if err != nil {
+				return err
+			}
+
+			err = c.Wait()
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				// The command has already reported its error.
+				cmd.SilenceErrors = true
+				return &exitCodeError{code: exitCode(exitErr.ProcessState)}
+			}
+			return err
+		},
+	}
+	// Treat flags after the command as its arguments.
+	cmd.Flags().SetInterspersed(false)
+
+	return cmd
+}
+
+// exitCode returns the exit code of the process, or 128 + the signal number if it was killed by a signal as shells do
+func exitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
+
+// credentialsVariables returns environment variables of credentials read by AWS SDKs and CLI
+func credentialsVariables(credentials sts.Credentials) []environmentVariable {
+	expiration := credentials.Expiration.UTC().Format(time.RFC3339)
+	return []environmentVariable{
+		{name: "AWS_ACCESS_KEY_ID", value: *credentials.AccessKeyId},
+		{name: "AWS_SECRET_ACCESS_KEY", value: *credentials.SecretAccessKey},
+		{name: "AWS_SESSION_TOKEN", value: *credentials.SessionToken},
+		{name: "AWS_SESSION_EXPIRATION", value: expiration},
+		{name: "AWS_CREDENTIAL_EXPIRATION", value: expiration},
+	}
+}
+
+// credentialsEnviron returns environ overridden by environment variables of credentials.
+// AWS_PROFILE is removed so that tools do not mix up the profile and the credentials.
+func credentialsEnviron(environ []string, credentials sts.Credentials) []string {
+	variables := credentialsVariables(credentials)
+	removed := map[string]bool{"AWS_PROFILE": true, "AWS_DEFAULT_PROFILE": true}
+	for _, v := range variables {
+		removed[v.name] = true
+	}
+
+	var ret []string
+	for _, e := range environ {
+		name := strings.SplitN(e, "=", 2)[0]
+		if removed[name] {
+			continue
+		}
+		ret = append(ret, e)
+	}
+	for _, v := range variables {
+		ret = append(ret, v.name+"="+v.value)
+	}
+
+	return ret
+}
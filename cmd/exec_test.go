@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialsEnviron(t *testing.T) {
+	// setup
+	environ := []string{
+		"PATH=/usr/bin",
+		"AWS_PROFILE=dev",
+		"AWS_ACCESS_KEY_ID=old",
+		"AWS_REGION=ap-northeast-1",
+	}
+	credentials := sts.Credentials{
+		AccessKeyId:     aws.String("access-key-id"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+		Expiration:      aws.Time(time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("JST", 9*60*60))),
+	}
+
+	// exercise
+	got := credentialsEnviron(environ, credentials)
+
+	// verify
+	assert.Equal(t, []string{
+		"PATH=/usr/bin",
+		"AWS_REGION=ap-northeast-1",
+		"AWS_ACCESS_KEY_ID=access-key-id",
+		"AWS_SECRET_ACCESS_KEY=secret-access-key",
+		"AWS_SESSION_TOKEN=session-token",
+		"AWS_SESSION_EXPIRATION=2024-01-01T18:04:05Z",
+		"AWS_CREDENTIAL_EXPIRATION=2024-01-01T18:04:05Z",
+	}, got)
+}
+
+func TestExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals are not supported on Windows")
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		want   int
+	}{
+		{name: "exit code of the command", script: "exit 3", want: 3},
+		{name: "128 + signal number when killed by a signal", script: "kill -TERM $$", want: 143},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := exec.Command("sh", "-c", tt.script).Run()
+
+			var exitErr *exec.ExitError
+			if assert.True(t, errors.As(err, &exitErr)) {
+				assert.Equal(t, tt.want, exitCode(exitErr.ProcessState))
+			}
+		})
+	}
+}
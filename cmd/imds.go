@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	imdsTokenPath               = "/latest/api/token"
+	imdsSecurityCredentialsPath = "/latest/meta-data/iam/security-credentials/"
+	imdsTokenHeader             = "X-aws-ec2-metadata-token"
+	imdsTokenTTLHeader          = "X-aws-ec2-metadata-token-ttl-seconds"
+	imdsMaxTokenTTL             = 6 * time.Hour
+)
+
+// imdsCredentials is the response of the security credentials endpoint of EC2 instance metadata service
+// ref: https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/instance-metadata-security-credentials.html
+type imdsCredentials struct {
+	Code            string
+	LastUpdated     string
+	Type            string
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+	Expiration      string
+}
+
+func newIMDSCmd(opts *options) *cobra.Command {
+	var addr string
+	var shell string
+
+	cmd := &cobra.Command{
+		Use:   "imds",
+		Short: "Serve credentials by an emulator of EC2 instance metadata service",
+		Long: `Serve credentials of the profile by an emulator of EC2 instance metadata service (IMDSv2).
+Credentials are refreshed in background when they are about to expire, and the current ones are served until then,
+because IMDS clients give up in a second. Refreshes assume the role selected at the start without asking the user.
+It prints shell commands to set AWS_EC2_METADATA_SERVICE_ENDPOINT, and serves until it is interrupted.
+Tools which always access 169.254.169.254 need --addr 169.254.169.254:80 and the address assigned to the host.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			shell, err := resolveShell(shell)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			r := newRefresher(ctx, *opts)
+			_, err = r.Credentials(ctx)
+			if err != nil {
+				return err
+			}
+
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+
+			err = printEnvironmentVariables(cmd.OutOrStdout(), shell, []environmentVariable{
+				{name: "AWS_EC2_METADATA_SERVICE_ENDPOINT", value: fmt.Sprintf("http://%s/", listener.Addr())},
+			})
+			if err != nil {
+				listener.Close()
+				return err
+			}
+
+			return serveHTTP(ctx, listener, newIMDSHandler(r, opts.profile))
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:0", "address to listen on")
+	cmd.Flags().StringVar(&shell, "shell", "", fmt.Sprintf("shell syntax (%s). Detected from $SHELL by default", strings.Join(shells, ", ")))
+
+	return cmd
+}
+
+// imdsHandler serves the session token and security credentials endpoints of IMDSv2
+type imdsHandler struct {
+	refresher *refresher
+	// roleName is the name of the instance profile role, which is the profile name.
+	roleName string
+
+	mu     sync.Mutex
+	tokens map[string]time.Time
+}
+
+func newIMDSHandler(r *refresher, roleName string) *imdsHandler {
+	return &imdsHandler{
+		refresher: r,
+		roleName:  roleName,
+		tokens:    map[string]time.Time{},
+	}
+}
+
+func (h *imdsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == imdsTokenPath {
+		h.serveToken(w, req)
+		return
+	}
+
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.validToken(req.Header.Get(imdsTokenHeader)) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch req.URL.Path {
+	case imdsSecurityCredentialsPath:
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, h.roleName)
+	case imdsSecurityCredentialsPath + h.roleName:
+		h.serveCredentials(w, req)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// serveToken issues a session token valid for the TTL in the request header
+func (h *imdsHandler) serveToken(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// IMDS rejects requests through proxies to protect against SSRF.
+	if req.Header.Get("X-Forwarded-For") != "" {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	ttl, err := strconv.Atoi(req.Header.Get(imdsTokenTTLHeader))
+	if err != nil || ttl < 1 || time.Duration(ttl)*time.Second > imdsMaxTokenTTL {
+		http.Error(w, fmt.Sprintf("%s must be between 1 and %d", imdsTokenTTLHeader, int(imdsMaxTokenTTL.Seconds())), http.StatusBadRequest)
+		return
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	h.mu.Lock()
+	for t, expiration := range h.tokens {
+		if !now.Before(expiration) {
+			delete(h.tokens, t)
+		}
+	}
+	h.tokens[token] = now.Add(time.Duration(ttl) * time.Second)
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set(imdsTokenTTLHeader, strconv.Itoa(ttl))
+	fmt.Fprint(w, token)
+}
+
+func (h *imdsHandler) serveCredentials(w http.ResponseWriter, req *http.Request) {
+	credentials, err := h.refresher.Credentials(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(imdsCredentials{
+		Code:            "Success",
+		LastUpdated:     time.Now().UTC().Format(time.RFC3339),
+		Type:            "AWS-HMAC",
+		AccessKeyID:     *credentials.AccessKeyId,
+		SecretAccessKey: *credentials.SecretAccessKey,
+		Token:           *credentials.SessionToken,
+		Expiration:      credentials.Expiration.UTC().Format(time.RFC3339),
+	})
+}
+
+func (h *imdsHandler) validToken(token string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	expiration, ok := h.tokens[token]
+	return ok && time.Now().Before(expiration)
+}
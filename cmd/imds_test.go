@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIMDSHandler(t *testing.T) {
+	// setup
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	r := &refresher{credentials: &sts.Credentials{
+		AccessKeyId:     aws.String("access-key-id"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+		Expiration:      &expiration,
+	}}
+	handler := newIMDSHandler(r, "dev")
+
+	serve := func(method, path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPut, imdsTokenPath, map[string]string{imdsTokenTTLHeader: "21600"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "21600", rec.Header().Get(imdsTokenTTLHeader))
+	token := rec.Body.String()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     map[string]string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "rejects a token request without TTL",
+			method:     http.MethodPut,
+			path:       imdsTokenPath,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "rejects a token request with too long TTL",
+			method:     http.MethodPut,
+			path:       imdsTokenPath,
+			header:     map[string]string{imdsTokenTTLHeader: "21601"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "rejects a token request through a proxy",
+			method:     http.MethodPut,
+			path:       imdsTokenPath,
+			header:     map[string]string{imdsTokenTTLHeader: "60", "X-Forwarded-For": "192.0.2.1"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "rejects a request without a token",
+			method:     http.MethodGet,
+			path:       imdsSecurityCredentialsPath,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "rejects a request with an invalid token",
+			method:     http.MethodGet,
+			path:       imdsSecurityCredentialsPath,
+			header:     map[string]string{imdsTokenHeader: "invalid"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "returns the role name",
+			method:     http.MethodGet,
+			path:       imdsSecurityCredentialsPath,
+			header:     map[string]string{imdsTokenHeader: token},
+			wantStatus: http.StatusOK,
+			wantBody:   "dev",
+		},
+		{
+			name:       "returns not found for other roles",
+			method:     http.MethodGet,
+			path:       imdsSecurityCredentialsPath + "other",
+			header:     map[string]string{imdsTokenHeader: token},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// exercise
+			rec := serve(tt.method, tt.path, tt.header)
+
+			// verify
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("returns credentials", func(t *testing.T) {
+		// exercise
+		rec := serve(http.MethodGet, imdsSecurityCredentialsPath+"dev", map[string]string{imdsTokenHeader: token})
+
+		// verify
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var got imdsCredentials
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, "Success", got.Code)
+		assert.Equal(t, "AWS-HMAC", got.Type)
+		assert.Equal(t, "access-key-id", got.AccessKeyID)
+		assert.Equal(t, "secret-access-key", got.SecretAccessKey)
+		assert.Equal(t, "session-token", got.Token)
+		assert.Equal(t, expiration.Format(time.RFC3339), got.Expiration)
+	})
+}
+
+func TestIMDSHandlerRefresh(t *testing.T) {
+	// setup
+	roleArn := "arn:aws:iam::012345678901:role/Admin"
+	setupRefreshedProfile(t, roleArn)
+	fake := newFakeIdP(t, roleArn)
+	started, release := blockAuthentication(t, fake)
+
+	r := newRefresher(context.Background(), options{profile: "dev", minRemaining: 15 * time.Minute})
+	r.credentials = &sts.Credentials{
+		AccessKeyId:     aws.String("current-access-key-id"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+		Expiration:      aws.Time(time.Now().Add(5 * time.Minute)),
+	}
+	handler := newIMDSHandler(r, "dev")
+
+	req := httptest.NewRequest(http.MethodPut, imdsTokenPath, nil)
+	req.Header.Set(imdsTokenTTLHeader, "60")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	token := rec.Body.String()
+
+	getCredentials := func() (int, imdsCredentials) {
+		// IMDS clients give up after about a second.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		req := httptest.NewRequest(http.MethodGet, imdsSecurityCredentialsPath+"dev", nil).WithContext(ctx)
+		req.Header.Set(imdsTokenHeader, token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var got imdsCredentials
+		_ = json.Unmarshal(rec.Body.Bytes(), &got)
+		return rec.Code, got
+	}
+
+	// exercise
+	code, first := getCredentials()
+	<-started
+	code2, second := getCredentials()
+	close(release)
+
+	// verify
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "current-access-key-id", first.AccessKeyID)
+	assert.Equal(t, http.StatusOK, code2)
+	assert.Equal(t, "current-access-key-id", second.AccessKeyID)
+	assert.Eventually(t, func() bool {
+		code, got := getCredentials()
+		return code == http.StatusOK && got.AccessKeyID == "access-key-id-of-"+roleArn
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, fake.authentications)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/config"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of list-roles subcommand.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputPlain = "plain"
+)
+
+var outputs = []string{outputTable, outputJSON, outputPlain}
+
+// roleOutput is a role in the output of list-roles subcommand
+type roleOutput struct {
+	AccountID    string
+	AccountAlias string `json:",omitempty"`
+	RoleName     string
+	Path         string
+	RoleArn      string
+	PrincipalArn string
+}
+
+func newListRolesCmd(opts *options) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "list-roles",
+		Short: "Print all roles in the SAML response",
+		Long: `Print all roles in the SAML response.
+The plain format prints a role ARN per line.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !isSupportedOutput(output) {
+				return fmt.Errorf("output must be one of %s: %s", strings.Join(outputs, ", "), output)
+			}
+
+			cfg, err := loadConfig(opts.profile)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			_, response, err := authenticate(ctx, cfg)
+			if err != nil {
+				return err
+			}
+
+			roles, err := aws.ExtractRoles(*response)
+			if err != nil {
+				return err
+			}
+
+			aliases, err := config.AccountAliases()
+			if err != nil {
+				return err
+			}
+
+			return printRoles(cmd.OutOrStdout(), output, newRoleOutputs(roles, aliases))
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", outputTable, fmt.Sprintf("output format (%s)", strings.Join(outputs, ", ")))
+
+	return cmd
+}
+
+func isSupportedOutput(output string) bool {
+	for _, o := range outputs {
+		if output == o {
+			return true
+		}
+	}
+	return false
+}
+
+func newRoleOutputs(roles []aws.Role, aliases map[string]string) []roleOutput {
+	ret := make([]roleOutput, len(roles))
+	for i, role := range roles {
+		ret[i] = roleOutput{
+			AccountID:    role.AccountID(),
+			AccountAlias: aliases[role.AccountID()],
+			RoleName:     role.Name(),
+			Path:         role.Path(),
+			RoleArn:      role.RoleArn,
+			PrincipalArn: role.PrincipalArn,
+		}
+	}
+	return ret
+}
+
+func printRoles(w io.Writer, output string, roles []roleOutput) error {
+	switch output {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(roles)
+	case outputPlain:
+		for _, role := range roles {
+			_, err := fmt.Fprintln(w, role.RoleArn)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		_, err := fmt.Fprintln(tw, "ACCOUNT\tALIAS\tROLE\tPATH\tPROVIDER")
+		if err != nil {
+			return err
+		}
+		for _, role := range roles {
+			_, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", role.AccountID, role.AccountAlias, role.RoleName, role.Path, role.PrincipalArn)
+			if err != nil {
+				return err
+			}
+		}
+		return tw.Flush()
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintRoles(t *testing.T) {
+	roles := newRoleOutputs([]aws.Role{
+		{
+			RoleArn:      "arn:aws:iam::012345678901:role/Admin",
+			PrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider",
+		},
+		{
+			RoleArn:      "arn:aws:iam::123456789012:role/path/ReadOnly",
+			PrincipalArn: "arn:aws:iam::123456789012:saml-provider/TestProvider",
+		},
+	}, map[string]string{"012345678901": "payments-prod"})
+
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{
+			output: outputTable,
+			want: "ACCOUNT       ALIAS          ROLE      PATH    PROVIDER\n" +
+				"012345678901  payments-prod  Admin     /       arn:aws:iam::012345678901:saml-provider/TestProvider\n" +
+				"123456789012                 ReadOnly  /path/  arn:aws:iam::123456789012:saml-provider/TestProvider\n",
+		},
+		{
+			output: outputJSON,
+			want: `[
+  {
+    "AccountID": "012345678901",
+    "AccountAlias": "payments-prod",
+    "RoleName": "Admin",
+    "Path": "/",
+    "RoleArn": "arn:aws:iam::012345678901:role/Admin",
+    "PrincipalArn": "arn:aws:iam::012345678901:saml-provider/TestProvider"
+  },
+  {
+    "AccountID": "123456789012",
+    "RoleName": "ReadOnly",
+    "Path": "/path/",
+    "RoleArn": "arn:aws:iam::123456789012:role/path/ReadOnly",
+    "PrincipalArn": "arn:aws:iam::123456789012:saml-provider/TestProvider"
+  }
+]
+`,
+		},
+		{
+			output: outputPlain,
+			want:   "arn:aws:iam::012345678901:role/Admin\narn:aws:iam::123456789012:role/path/ReadOnly\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			err := printRoles(buf, tt.output, roles)
+			if err != nil {
+				t.Errorf("printRoles() error = %v", err)
+				return
+			}
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
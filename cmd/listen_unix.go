@@ -0,0 +1,18 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenUnix listens on the Unix socket created with permission 0600,
+// so that other users cannot connect to it even for a moment before it is chmoded.
+// It changes umask of the process while listening, so it must be called before other goroutines create files.
+func listenUnix(socket string) (net.Listener, error) {
+	mask := syscall.Umask(0177)
+	defer syscall.Umask(mask)
+
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,10 @@
+package cmd
+
+import (
+	"net"
+)
+
+// listenUnix listens on the Unix socket. Windows has no umask, and the socket inherits ACL of the directory.
+func listenUnix(socket string) (net.Listener, error) {
+	return net.Listen("unix", socket)
+}
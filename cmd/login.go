@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/config"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newLoginCmd(opts *options) *cobra.Command {
+	var profiles []string
+	var group string
+
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Save credentials of several profiles with a single authentication",
+		Long: `Save credentials of several profiles with a single authentication.
+Each profile assumes its assam_role_arn or assam_role_name, and all profiles must use the same IdP settings.
+A profile which fails does not stop the others, and the errors are reported per profile.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			profiles, err := groupProfiles(profiles, group)
+			if err != nil {
+				return err
+			}
+			if len(profiles) == 0 {
+				return errors.New("please specify --profiles or --group")
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			return login(ctx, opts, profiles)
+		},
+	}
+	cmd.Flags().StringSliceVar(&profiles, "profiles", nil, "comma separated AWS profiles")
+	cmd.Flags().StringVar(&group, "group", "", "profile group defined as [assam-group NAME] in AWS config file")
+
+	return cmd
+}
+
+// groupProfiles returns profiles of the group if specified, otherwise profiles
+func groupProfiles(profiles []string, group string) ([]string, error) {
+	if group == "" {
+		return profiles, nil
+	}
+
+	profiles, err := config.GroupProfiles(group)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load profile group %s", group)
+	}
+	return profiles, nil
+}
+
+// profileError is the error of a profile which login failed to save credentials of
+type profileError struct {
+	profile string
+	err     error
+}
+
+func (e profileError) Error() string {
+	return fmt.Sprintf("profile %s: %s", e.profile, e.err)
+}
+
+// profileErrors are the errors of profiles which login failed to save credentials of,
+// while it saved credentials of the other profiles
+type profileErrors []profileError
+
+func (e profileErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// failed returns whether login failed to save credentials of the profile
+func (e profileErrors) failed(profile string) bool {
+	for _, err := range e {
+		if err.profile == profile {
+			return true
+		}
+	}
+	return false
+}
+
+// login authenticates once and saves credentials of the profiles.
+// A failure of a profile does not stop the others, and it returns profileErrors of the failed profiles.
+func login(ctx context.Context, opts *options, profiles []string) error {
+	configs := make([]config.Config, len(profiles))
+	filters := make([]aws.RoleFilter, len(profiles))
+	var pending []int
+	var failures profileErrors
+	for i, profile := range profiles {
+		cfg, err := loadConfig(profile)
+		if err != nil {
+			failures = append(failures, profileError{profile: profile, err: err})
+			continue
+		}
+		configs[i] = cfg
+
+		filters[i], err = newRoleFilter(opts, cfg)
+		if err != nil {
+			failures = append(failures, profileError{profile: profile, err: err})
+			continue
+		}
+
+		entry, err := loadCache(opts, profile, cfg, filters[i])
+		if err != nil {
+			failures = append(failures, profileError{profile: profile, err: err})
+			continue
+		}
+		if entry != nil {
+			err = saveCredentials(profile, cfg, entry.Credentials)
+			if err != nil {
+				failures = append(failures, profileError{profile: profile, err: err})
+			}
+			continue
+		}
+
+		if len(pending) != 0 && !configs[pending[0]].SameIdentityProvider(cfg) {
+			return fmt.Errorf("profile %s uses different IdP settings from profile %s", profile, profiles[pending[0]])
+		}
+		pending = append(pending, i)
+	}
+
+	if len(pending) != 0 {
+		base64Response, response, err := authenticateFunc(ctx, configs[pending[0]])
+		if err != nil {
+			return err
+		}
+
+		for _, i := range pending {
+			profile := profiles[i]
+
+			entry, err := assumeRoleWithSAML(ctx, opts, profile, configs[i], filters[i], *response, base64Response)
+			if err != nil {
+				failures = append(failures, profileError{profile: profile, err: err})
+				continue
+			}
+
+			err = saveCredentials(profile, configs[i], entry.Credentials)
+			if err != nil {
+				failures = append(failures, profileError{profile: profile, err: err})
+				continue
+			}
+			opts.logf("Saved credentials of profile %s", profile)
+		}
+	}
+
+	if len(failures) != 0 {
+		return failures
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/cache"
+	"github.com/cybozu/assam/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeIdP replaces authentication with the IdP and AssumeRoleWithSAML during a test
+type fakeIdP struct {
+	authentications int
+	assumedRoleArns []string
+}
+
+func newFakeIdP(t *testing.T, roleArns ...string) *fakeIdP {
+	fake := &fakeIdP{}
+
+	var values []aws.AttributeValue
+	for _, roleArn := range roleArns {
+		values = append(values, aws.AttributeValue{Value: roleArn + ",arn:aws:iam::012345678901:saml-provider/TestProvider"})
+	}
+	response := &aws.SAMLResponse{
+		Assertion: aws.Assertion{
+			AttributeStatement: aws.AttributeStatement{
+				Attributes: []aws.Attribute{{Name: "https://aws.amazon.com/SAML/Attributes/Role", AttributeValues: values}},
+			},
+		},
+	}
+
+	authenticate, assume := authenticateFunc, assumeRoleWithSAMLFunc
+	t.Cleanup(func() {
+		authenticateFunc, assumeRoleWithSAMLFunc = authenticate, assume
+	})
+	authenticateFunc = func(_ context.Context, _ config.Config) (string, *aws.SAMLResponse, error) {
+		fake.authentications++
+		return "base64-response", response, nil
+	}
+	assumeRoleWithSAMLFunc = func(_ context.Context, _ int, roleArn string, _ string, _ string) (*sts.Credentials, error) {
+		fake.assumedRoleArns = append(fake.assumedRoleArns, roleArn)
+		return &sts.Credentials{
+			AccessKeyId:     awssdk.String("access-key-id-of-" + roleArn),
+			SecretAccessKey: awssdk.String("secret-access-key"),
+			SessionToken:    awssdk.String("session-token"),
+			Expiration:      awssdk.Time(time.Now().Add(time.Hour)),
+		}, nil
+	}
+	return fake
+}
+
+// blockAuthentication makes authentications with the fake IdP wait until release is closed.
+// started receives a value when an authentication starts.
+func blockAuthentication(t *testing.T, fake *fakeIdP) (started <-chan struct{}, release chan<- struct{}) {
+	startedCh := make(chan struct{}, 1)
+	releaseCh := make(chan struct{})
+	authenticate := authenticateFunc
+	authenticateFunc = func(ctx context.Context, cfg config.Config) (string, *aws.SAMLResponse, error) {
+		startedCh <- struct{}{}
+		<-releaseCh
+		return authenticate(ctx, cfg)
+	}
+	return startedCh, releaseCh
+}
+
+func TestLogin(t *testing.T) {
+	setup := func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("HOME", dir)
+		t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+		t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+		err := os.WriteFile(filepath.Join(dir, "config"), []byte(`[profile dev]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+assam_role_arn = arn:aws:iam::012345678901:role/Dev
+
+[profile stg]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+assam_role_arn = arn:aws:iam::012345678901:role/Stg
+
+[profile okta]
+idp = okta
+okta_org_url = https://example.okta.com
+okta_app_embed_path = /home/amazon_aws/0oa1b2c3d4/272
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+`), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := &options{minRemaining: 15 * time.Minute}
+
+	t.Run("assumes the role of each profile from one SAML response", func(t *testing.T) {
+		// setup
+		setup(t)
+		fake := newFakeIdP(t, "arn:aws:iam::012345678901:role/Dev", "arn:aws:iam::012345678901:role/Stg")
+
+		// exercise
+		err := login(context.Background(), opts, []string{"dev", "stg"})
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 1, fake.authentications)
+		assert.Equal(t, []string{"arn:aws:iam::012345678901:role/Dev", "arn:aws:iam::012345678901:role/Stg"}, fake.assumedRoleArns)
+		assertSavedAccessKeyID(t, "dev", "access-key-id-of-arn:aws:iam::012345678901:role/Dev")
+		assertSavedAccessKeyID(t, "stg", "access-key-id-of-arn:aws:iam::012345678901:role/Stg")
+	})
+
+	t.Run("reuses cached credentials per profile", func(t *testing.T) {
+		// setup
+		setup(t)
+		fake := newFakeIdP(t, "arn:aws:iam::012345678901:role/Dev", "arn:aws:iam::012345678901:role/Stg")
+		err := cache.Save("dev", cache.Entry{
+			RoleArn: "arn:aws:iam::012345678901:role/Dev",
+			Credentials: sts.Credentials{
+				AccessKeyId:     awssdk.String("cached-access-key-id"),
+				SecretAccessKey: awssdk.String("secret-access-key"),
+				SessionToken:    awssdk.String("session-token"),
+				Expiration:      awssdk.Time(time.Now().Add(time.Hour)),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// exercise
+		err = login(context.Background(), opts, []string{"dev", "stg"})
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, 1, fake.authentications)
+		assert.Equal(t, []string{"arn:aws:iam::012345678901:role/Stg"}, fake.assumedRoleArns)
+		assertSavedAccessKeyID(t, "dev", "cached-access-key-id")
+		assertSavedAccessKeyID(t, "stg", "access-key-id-of-arn:aws:iam::012345678901:role/Stg")
+	})
+
+	t.Run("saves credentials of the other profiles when a profile fails", func(t *testing.T) {
+		// setup
+		setup(t)
+		fake := newFakeIdP(t, "arn:aws:iam::012345678901:role/Stg")
+
+		// exercise
+		err := login(context.Background(), opts, []string{"dev", "stg"})
+
+		// verify
+		var failures profileErrors
+		if assert.ErrorAs(t, err, &failures) && assert.Len(t, failures, 1) {
+			assert.Equal(t, "dev", failures[0].profile)
+		}
+		assert.Equal(t, 1, fake.authentications)
+		assert.Equal(t, []string{"arn:aws:iam::012345678901:role/Stg"}, fake.assumedRoleArns)
+		assertSavedAccessKeyID(t, "stg", "access-key-id-of-arn:aws:iam::012345678901:role/Stg")
+	})
+
+	t.Run("rejects profiles of different IdP settings", func(t *testing.T) {
+		// setup
+		setup(t)
+		fake := newFakeIdP(t, "arn:aws:iam::012345678901:role/Dev")
+
+		// exercise
+		err := login(context.Background(), opts, []string{"dev", "okta"})
+
+		// verify
+		assert.Error(t, err)
+		assert.Equal(t, 0, fake.authentications)
+	})
+}
+
+func assertSavedAccessKeyID(t *testing.T, profile string, want string) {
+	t.Helper()
+	credentials, err := aws.NewFileStore().Load(profile)
+	if assert.NoError(t, err) && assert.NotNil(t, credentials) {
+		assert.Equal(t, want, *credentials.AccessKeyId)
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/cache"
+	"github.com/cybozu/assam/idp"
+	"github.com/spf13/cobra"
+)
+
+func newLogoutCmd(opts *options) *cobra.Command {
+	var clearIdPSession bool
+
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Delete credentials of the profile",
+		Long: `Delete credentials of the profile from the AWS credentials file, its credential store and the cache.
+With --idp, cookies of the IdP are also deleted from the Chrome user data directory,
+so that the next login asks for an account again.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			err := aws.DeleteCredentials(opts.profile)
+			if err != nil {
+				return err
+			}
+
+			if cfg := storeConfig(opts.profile); !usesFileStore(cfg) {
+				err = deleteStoredCredentials(opts.profile, cfg)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = cache.Delete(opts.profile)
+			if err != nil {
+				return err
+			}
+			opts.logf("Deleted credentials of profile %s", opts.profile)
+
+			if !clearIdPSession {
+				return nil
+			}
+
+			cfg, err := loadConfig(opts.profile)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			err = idp.ClearSession(ctx, cfg)
+			if err != nil {
+				return err
+			}
+			opts.logf("Deleted cookies of %s from %s", cfg.IDP, cfg.ChromeUserDataDir)
+
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&clearIdPSession, "idp", false, "also delete cookies of the IdP from the Chrome user data directory")
+
+	return cmd
+}
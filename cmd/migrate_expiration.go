@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateExpirationCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate-expiration",
+		Short: "Rewrite aws_session_expiration written by old versions to RFC3339",
+		Long: `Rewrite aws_session_expiration in the AWS credentials file written by old versions of assam to RFC3339,
+so that other tools can parse it.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			profiles, err := aws.MigrateExpirations()
+			if err != nil {
+				return err
+			}
+
+			for _, profile := range profiles {
+				fmt.Fprintf(cmd.OutOrStdout(), "Migrated %s\n", profile)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/config"
+	"github.com/spf13/cobra"
+)
+
+const defaultProfileNameTemplate = "{account}-{role}"
+
+func newProfilesCmd(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "Manage AWS profiles",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newProfilesSyncCmd(opts))
+
+	return cmd
+}
+
+func newProfilesSyncCmd(opts *options) *cobra.Command {
+	var nameTemplate string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Create a profile for every role in the SAML response",
+		Long: `Create a profile for every role in the SAML response.
+Each profile takes over the settings of the profile specified by --profile.
+
+The profile name template accepts the following placeholders:
+  {account}  AWS account ID
+  {alias}    alias of the AWS account, or the account ID if it has no alias
+  {role}     IAM role name`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := loadConfig(opts.profile)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			_, response, err := authenticate(ctx, cfg)
+			if err != nil {
+				return err
+			}
+
+			roles, err := aws.ExtractRoles(*response)
+			if err != nil {
+				return err
+			}
+
+			aliases, err := config.AccountAliases()
+			if err != nil {
+				return err
+			}
+
+			profiles, err := profileNames(nameTemplate, roles, aliases)
+			if err != nil {
+				return err
+			}
+
+			for i, role := range roles {
+				profile := profiles[i]
+
+				if !dryRun {
+					profileCfg, err := profileConfig(cfg, profile, role)
+					if err != nil {
+						return err
+					}
+
+					err = config.Save(profileCfg, profile)
+					if err != nil {
+						return err
+					}
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", profile, role.RoleArn)
+			}
+
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&nameTemplate, "name-template", defaultProfileNameTemplate, "template of profile names")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print profiles without saving them")
+
+	return cmd
+}
+
+// profileName returns the profile name of the role generated from the template
+func profileName(template string, role aws.Role, aliases map[string]string) string {
+	return strings.NewReplacer(
+		"{account}", role.AccountID(),
+		"{alias}", accountName(aliases, role.AccountID()),
+		"{role}", role.Name(),
+	).Replace(template)
+}
+
+// profileNames returns the profile names of the roles generated from the template.
+// It returns an error if roles get the same name, which would overwrite each other's profile.
+func profileNames(template string, roles []aws.Role, aliases map[string]string) ([]string, error) {
+	names := make([]string, len(roles))
+	roleArns := map[string]string{}
+	for i, role := range roles {
+		names[i] = profileName(template, role, aliases)
+		if roleArn, ok := roleArns[names[i]]; ok {
+			return nil, fmt.Errorf("profile name %s is generated for both %s and %s. Change --name-template", names[i], roleArn, role.RoleArn)
+		}
+		roleArns[names[i]] = role.RoleArn
+	}
+	return names, nil
+}
+
+// profileConfig returns the config of the profile which assumes the role with the IdP settings of base
+func profileConfig(base config.Config, profile string, role aws.Role) (config.Config, error) {
+	cfg := base
+	cfg.RoleArn = role.RoleArn
+	cfg.RoleChain = nil
+	if cfg.CredentialProcess != "" {
+		var err error
+		cfg.CredentialProcess, err = credentialProcessCommand(profile)
+		if err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/config"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/ini.v1"
+)
+
+func TestProfileName(t *testing.T) {
+	role := aws.Role{
+		RoleArn:      "arn:aws:iam::012345678901:role/path/Admin",
+		PrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		aliases  map[string]string
+		want     string
+	}{
+		{name: "default", template: defaultProfileNameTemplate, aliases: map[string]string{"012345678901": "payments-prod"}, want: "012345678901-Admin"},
+		{name: "account ID instead of missing alias", template: "{alias}-{role}", want: "012345678901-Admin"},
+		{name: "alias", template: "{alias}-{role}", aliases: map[string]string{"012345678901": "payments-prod"}, want: "payments-prod-Admin"},
+		{name: "account ID", template: "{role}@{account}", aliases: map[string]string{"012345678901": "payments-prod"}, want: "Admin@012345678901"},
+		{name: "no placeholder", template: "static", want: "static"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, profileName(tt.template, role, tt.aliases))
+		})
+	}
+}
+
+func TestProfileNames(t *testing.T) {
+	t.Run("returns names of the roles", func(t *testing.T) {
+		roles := []aws.Role{
+			{RoleArn: "arn:aws:iam::012345678901:role/Admin"},
+			{RoleArn: "arn:aws:iam::012345678901:role/ReadOnly"},
+		}
+
+		got, err := profileNames("{account}-{role}", roles, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"012345678901-Admin", "012345678901-ReadOnly"}, got)
+	})
+
+	t.Run("returns an error for duplicate names", func(t *testing.T) {
+		roles := []aws.Role{
+			{RoleArn: "arn:aws:iam::012345678901:role/Admin"},
+			{RoleArn: "arn:aws:iam::012345678901:role/path/Admin"},
+		}
+
+		_, err := profileNames("{account}-{role}", roles, nil)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSaveProfileConfig(t *testing.T) {
+	// setup
+	file := filepath.Join(t.TempDir(), "config")
+	t.Setenv("AWS_CONFIG_FILE", file)
+	// The profile was generated by an old version which saved the role as role_arn.
+	err := os.WriteFile(file, []byte(`[profile 012345678901-Admin]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+role_arn = arn:aws:iam::012345678901:role/Admin
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := config.Config{IDP: config.IDPGeneric, SAMLStartURL: "https://idp.example.com/start", DefaultSessionDurationHours: 1, ChromeUserDataDir: "/tmp/assam"}
+	role := aws.Role{RoleArn: "arn:aws:iam::012345678901:role/Admin", PrincipalArn: "arn:aws:iam::012345678901:saml-provider/TestProvider"}
+
+	// exercise
+	cfg, err := profileConfig(base, "012345678901-Admin", role)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = config.Save(cfg, "012345678901-Admin")
+
+	// verify
+	assert.NoError(t, err)
+	f, err := ini.Load(file)
+	if assert.NoError(t, err) {
+		section := f.Section("profile 012345678901-Admin")
+		assert.Equal(t, role.RoleArn, section.Key("assam_role_arn").String())
+		// AWS SDKs and CLI would assume role_arn again with the credentials of the profile.
+		assert.False(t, section.HasKey("role_arn"))
+	}
+}
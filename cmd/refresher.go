@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+// refreshRetryInterval is the interval to retry a failed refresh while the current credentials are still valid
+const refreshRetryInterval = time.Minute
+
+// refresher keeps credentials of a profile for long-running servers,
+// and assumes the role again in background when they are about to expire
+type refresher struct {
+	// ctx is the context of the server, which refreshes run with instead of contexts of requests.
+	ctx context.Context
+
+	mu          sync.Mutex
+	opts        options
+	credentials *sts.Credentials
+	// refreshing is closed when the running refresh finishes. It is nil while no refresh is running.
+	refreshing chan struct{}
+	err        error
+	failedAt   time.Time
+}
+
+func newRefresher(ctx context.Context, opts options) *refresher {
+	return &refresher{ctx: ctx, opts: opts}
+}
+
+// Credentials returns credentials valid for at least --min-remaining.
+// While a refresh is running, it returns the current credentials until they expire,
+// because clients of credential endpoints give up in a few seconds.
+func (r *refresher) Credentials(ctx context.Context) (*sts.Credentials, error) {
+	r.mu.Lock()
+	if r.credentials != nil && time.Until(*r.credentials.Expiration) >= r.opts.minRemaining {
+		defer r.mu.Unlock()
+		return r.credentials, nil
+	}
+
+	valid := r.credentials != nil && time.Now().Before(*r.credentials.Expiration)
+	if valid && time.Since(r.failedAt) < refreshRetryInterval {
+		defer r.mu.Unlock()
+		return r.credentials, nil
+	}
+
+	done := r.refresh()
+	if valid {
+		defer r.mu.Unlock()
+		return r.credentials, nil
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.credentials == nil || !time.Now().Before(*r.credentials.Expiration) {
+		return nil, r.err
+	}
+	return r.credentials, nil
+}
+
+// refresh starts assuming the role in background unless it is running, and returns the channel closed when it finishes.
+// r.mu must be held.
+func (r *refresher) refresh() <-chan struct{} {
+	if r.refreshing != nil {
+		return r.refreshing
+	}
+
+	done := make(chan struct{})
+	r.refreshing = done
+	opts := r.opts
+	go func() {
+		defer close(done)
+
+		entry, err := assumeRoleEntry(r.ctx, &opts)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.refreshing = nil
+		r.err = err
+		if err != nil {
+			r.failedAt = time.Now()
+			if r.credentials != nil {
+				fmt.Fprintf(os.Stderr, "%s failed to refresh credentials of profile %s: %s\n", time.Now().Format(time.RFC3339), opts.profile, err)
+			}
+			return
+		}
+		r.opts.logf("Got credentials of profile %s (expires at %s)", r.opts.profile, entry.Credentials.Expiration.Local())
+
+		// --force only applies to the first authentication.
+		r.opts.force = false
+		// Refreshes assume the same role without asking the user, because they run in background of servers.
+		if r.opts.roleArn == "" {
+			r.opts.roleArn = entry.RoleArn
+		}
+		r.opts.noPrompt = true
+		r.credentials = &entry.Credentials
+	}()
+	return done
+}
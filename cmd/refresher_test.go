@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefresherCredentials(t *testing.T) {
+	// setup
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	err := os.WriteFile(filepath.Join(dir, "config"), []byte(`[profile dev]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roleArn := "arn:aws:iam::012345678901:role/Admin"
+	err = cache.Save("dev", cache.Entry{
+		RoleArn: roleArn,
+		Credentials: sts.Credentials{
+			AccessKeyId:     aws.String("access-key-id"),
+			SecretAccessKey: aws.String("secret-access-key"),
+			SessionToken:    aws.String("session-token"),
+			Expiration:      aws.Time(time.Now().Add(time.Hour)),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newRefresher(context.Background(), options{profile: "dev", minRemaining: 15 * time.Minute})
+
+	// exercise
+	got, err := r.Credentials(context.Background())
+
+	// verify
+	assert.NoError(t, err)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, "access-key-id", *got.AccessKeyId)
+	}
+	assert.Equal(t, roleArn, r.opts.roleArn)
+	assert.True(t, r.opts.noPrompt)
+}
+
+// setupRefreshedProfile writes the config of profile dev which assumes roleArn without cached credentials
+func setupRefreshedProfile(t *testing.T, roleArn string) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	err := os.WriteFile(filepath.Join(dir, "config"), []byte(`[profile dev]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+assam_role_arn = `+roleArn+`
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefresherCredentialsWhileRefreshing(t *testing.T) {
+	// setup
+	roleArn := "arn:aws:iam::012345678901:role/Admin"
+	setupRefreshedProfile(t, roleArn)
+	fake := newFakeIdP(t, roleArn)
+	started, release := blockAuthentication(t, fake)
+
+	r := newRefresher(context.Background(), options{profile: "dev", minRemaining: 15 * time.Minute})
+	r.credentials = &sts.Credentials{
+		AccessKeyId:     aws.String("current-access-key-id"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+		Expiration:      aws.Time(time.Now().Add(5 * time.Minute)),
+	}
+
+	// exercise
+	// Requests give up soon, which must not cancel the refresh.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	first, err := r.Credentials(ctx)
+	<-started
+	second, err2 := r.Credentials(ctx)
+	close(release)
+
+	// verify
+	assert.NoError(t, err)
+	assert.NoError(t, err2)
+	assert.Equal(t, "current-access-key-id", *first.AccessKeyId)
+	assert.Equal(t, "current-access-key-id", *second.AccessKeyId)
+	assert.Eventually(t, func() bool {
+		got, err := r.Credentials(context.Background())
+		return err == nil && *got.AccessKeyId == "access-key-id-of-"+roleArn
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, fake.authentications)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/config"
+	"github.com/cybozu/assam/prompt"
+	"github.com/pkg/errors"
+)
+
+// newRoleFilter returns aws.RoleFilter of the role given by the flags, or the default role of the profile
+func newRoleFilter(opts *options, cfg config.Config) (aws.RoleFilter, error) {
+	filter := aws.RoleFilter{
+		RoleArn:   opts.roleArn,
+		AccountID: opts.accountID,
+		RoleName:  opts.roleName,
+	}
+	if filter.AccountID != "" {
+		aliases, err := config.AccountAliases()
+		if err != nil {
+			return filter, err
+		}
+		filter.AccountID = resolveAccountAlias(aliases, filter.AccountID)
+	}
+	if opts.roleRegexp != "" {
+		re, err := regexp.Compile(opts.roleRegexp)
+		if err != nil {
+			return filter, errors.Wrap(err, "invalid --role-regexp")
+		}
+		filter.Regexp = re
+	}
+
+	switch {
+	case !filter.IsEmpty():
+		return filter, nil
+	case cfg.RoleArn != "":
+		return aws.RoleFilter{RoleArn: cfg.RoleArn}, nil
+	default:
+		return aws.RoleFilter{RoleName: cfg.RoleName}, nil
+	}
+}
+
+// roleSelectionError is returned when the role options do not select a single role
+type roleSelectionError struct {
+	error
+}
+
+// selectRole selects the role to assume from SAML response.
+// When no role is specified and several roles are available, it lets the user pick one in interactive sessions unless noPrompt.
+func selectRole(response aws.SAMLResponse, filter aws.RoleFilter, noPrompt bool) (aws.Role, error) {
+	roles, err := aws.ExtractRoles(response)
+	if err != nil {
+		return aws.Role{}, err
+	}
+
+	switch {
+	case !filter.IsEmpty() || (noPrompt && len(roles) > 1):
+		role, err := aws.SelectRole(roles, filter)
+		if err != nil {
+			return aws.Role{}, roleSelectionError{err}
+		}
+		return role, nil
+	case len(roles) == 1 || !prompt.IsInteractive():
+		return roles[0], nil
+	}
+
+	aliases, err := config.AccountAliases()
+	if err != nil {
+		return aws.Role{}, err
+	}
+
+	choices := make([]string, len(roles))
+	for i, role := range roles {
+		choices[i] = fmt.Sprintf("%s / %s", accountName(aliases, role.AccountID()), role.PathName())
+	}
+
+	p := prompt.NewStderrPrompt()
+	i, err := p.AskSelect("Select a role (number or text to filter)", choices, nil)
+	if err != nil {
+		return aws.Role{}, err
+	}
+
+	return roles[i], nil
+}
+
+// accountName returns the alias of the AWS account, or the account ID if it has no alias
+func accountName(aliases map[string]string, accountID string) string {
+	if alias, ok := aliases[accountID]; ok && alias != "" {
+		return alias
+	}
+	return accountID
+}
+
+// resolveAccountAlias returns the account ID of the alias. It returns the argument as is if it is not an alias.
+func resolveAccountAlias(aliases map[string]string, alias string) string {
+	for accountID, v := range aliases {
+		if v == alias {
+			return accountID
+		}
+	}
+	return alias
+}
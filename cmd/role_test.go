@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRoleFilter(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(configFile, []byte("[assam-account-aliases]\n123456789012 = payments-prod\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+
+	tests := []struct {
+		name    string
+		opts    options
+		cfg     config.Config
+		want    aws.RoleFilter
+		wantErr bool
+	}{
+		{
+			name: "prefers the flags to the config",
+			opts: options{roleName: "FlagRole", accountID: "012345678901"},
+			cfg:  config.Config{RoleArn: "arn:aws:iam::012345678901:role/ConfigRole", RoleName: "ConfigRole"},
+			want: aws.RoleFilter{RoleName: "FlagRole", AccountID: "012345678901"},
+		},
+		{
+			name: "resolves the account alias",
+			opts: options{accountID: "payments-prod"},
+			want: aws.RoleFilter{AccountID: "123456789012"},
+		},
+		{
+			name: "prefers assam_role_arn to assam_role_name",
+			cfg:  config.Config{RoleArn: "arn:aws:iam::012345678901:role/ConfigRole", RoleName: "ConfigRole"},
+			want: aws.RoleFilter{RoleArn: "arn:aws:iam::012345678901:role/ConfigRole"},
+		},
+		{
+			name: "uses assam_role_name without assam_role_arn",
+			cfg:  config.Config{RoleName: "ConfigRole"},
+			want: aws.RoleFilter{RoleName: "ConfigRole"},
+		},
+		{
+			name: "selects any role without role settings",
+			want: aws.RoleFilter{},
+		},
+		{
+			name:    "returns an error when the regexp is invalid",
+			opts:    options{roleRegexp: "("},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newRoleFilter(&tt.opts, tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newRoleFilter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSelectRole(t *testing.T) {
+	response := aws.SAMLResponse{
+		Assertion: aws.Assertion{
+			AttributeStatement: aws.AttributeStatement{
+				Attributes: []aws.Attribute{
+					{
+						Name: "https://aws.amazon.com/SAML/Attributes/Role",
+						AttributeValues: []aws.AttributeValue{
+							{Value: "arn:aws:iam::012345678901:role/Admin,arn:aws:iam::012345678901:saml-provider/TestProvider"},
+							{Value: "arn:aws:iam::012345678901:role/ReadOnly,arn:aws:iam::012345678901:saml-provider/TestProvider"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("selects the role matching the filter", func(t *testing.T) {
+		got, err := selectRole(response, aws.RoleFilter{RoleName: "ReadOnly"}, true)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "arn:aws:iam::012345678901:role/ReadOnly", got.RoleArn)
+	})
+
+	t.Run("returns an error listing candidates instead of prompting", func(t *testing.T) {
+		_, err := selectRole(response, aws.RoleFilter{}, true)
+
+		var roleErr roleSelectionError
+		assert.ErrorAs(t, err, &roleErr)
+		assert.Contains(t, err.Error(), "arn:aws:iam::012345678901:role/ReadOnly")
+	})
+
+	t.Run("returns an error when no role matches", func(t *testing.T) {
+		_, err := selectRole(response, aws.RoleFilter{RoleName: "Missing"}, false)
+
+		var roleErr roleSelectionError
+		assert.ErrorAs(t, err, &roleErr)
+	})
+}
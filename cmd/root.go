@@ -10,7 +10,6 @@ import (
 	"github.com/cybozu/assam/aws"
 	"github.com/cybozu/assam/config"
 	"github.com/cybozu/assam/defaults"
-	"github.com/cybozu/assam/idp"
 	"github.com/cybozu/assam/prompt"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -21,6 +20,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"syscall"
+	"time"
 )
 
 // goreleaser embed variables by ldflags
@@ -33,16 +33,47 @@ var (
 // Execute runs root command
 func Execute() {
 	if err := newRootCmd().Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		// Not print an error because cobra.Command prints it.
 		os.Exit(1)
 	}
 }
 
+// options holds the flags shared by assam and its subcommands
+type options struct {
+	profile      string
+	roleName     string
+	roleArn      string
+	accountID    string
+	roleRegexp   string
+	force        bool
+	minRemaining time.Duration
+	verbose      bool
+	// fetchAccountAlias saves the alias of the AWS account after assuming the role.
+	fetchAccountAlias bool
+	// noPrompt makes an ambiguous role an error instead of letting the user pick one,
+	// because servers in background are stopped by reading the terminal.
+	noPrompt bool
+	// memoryOnly keeps assumed credentials only in memory instead of saving them to the cache,
+	// because assam agent must not write secrets in plaintext.
+	memoryOnly bool
+}
+
+// logf prints a message to stderr in verbose mode
+func (o *options) logf(format string, a ...interface{}) {
+	if o.verbose {
+		fmt.Fprintf(os.Stderr, format+"\n", a...)
+	}
+}
+
 func newRootCmd() *cobra.Command {
+	var opts options
 	var configure bool
-	var roleName string
-	var profile string
 	var web bool
+	var verify bool
 	var showVersion bool
 
 	cmd := &cobra.Command{
@@ -57,7 +88,7 @@ func newRootCmd() *cobra.Command {
 			}
 
 			if configure {
-				err := configureSettings(profile)
+				err := configureSettings(opts.profile)
 				if err != nil {
 					return err
 				}
@@ -68,56 +99,57 @@ func newRootCmd() *cobra.Command {
 				return openBrowser()
 			}
 
-			cfg, err := config.NewConfig(profile)
-			if err != nil {
-				return errors.Wrap(err, "please run `assam --configure` at the first time")
-			}
-
-			request, err := aws.CreateSAMLRequest(cfg.AppIDURI)
-			if err != nil {
-				return err
-			}
-
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
 			handleSignal(cancel)
 
-			azure := idp.NewAzure(request, cfg.AzureTenantID)
-			base64Response, err := azure.Authenticate(ctx, cfg.ChromeUserDataDir)
-			if err != nil {
-				return err
-			}
-
-			response, err := aws.ParseSAMLResponse(base64Response)
-			if err != nil {
-				return err
-			}
-
-			roleArn, principalArn, err := aws.ExtractRoleArnAndPrincipalArn(*response, roleName)
+			credentials, err := assumeRole(ctx, &opts)
 			if err != nil {
 				return err
 			}
 
-			credentials, err := aws.AssumeRoleWithSAML(ctx, cfg.DefaultSessionDurationHours, roleArn, principalArn, base64Response)
+			err = saveCredentials(opts.profile, storeConfig(opts.profile), *credentials)
 			if err != nil {
 				return err
 			}
 
-			err = aws.SaveCredentials(profile, *credentials)
-			if err != nil {
-				return err
+			if verify {
+				return verifyCredentials(ctx, os.Stdout, opts.profile)
 			}
 
 			return nil
 		},
 	}
 	cmd.PersistentFlags().BoolVarP(&configure, "configure", "c", false, "configure initial settings")
-	cmd.PersistentFlags().StringVarP(&profile, "profile", "p", "default", "AWS profile")
-	cmd.PersistentFlags().StringVarP(&roleName, "role", "r", "", "AWS IAM role name")
+	cmd.PersistentFlags().StringVarP(&opts.profile, "profile", "p", "default", "AWS profile")
+	cmd.PersistentFlags().StringVarP(&opts.roleName, "role", "r", "", "AWS IAM role name with or without the path (default: assam_role_arn or assam_role_name of the profile)")
+	cmd.PersistentFlags().StringVar(&opts.roleArn, "role-arn", "", "AWS IAM role ARN")
+	cmd.PersistentFlags().StringVar(&opts.accountID, "account", "", "AWS account ID of the role")
+	cmd.PersistentFlags().StringVar(&opts.roleRegexp, "role-regexp", "", "regular expression matching the role ARN")
+	cmd.PersistentFlags().BoolVarP(&opts.force, "force", "f", false, "authenticate even if cached credentials are valid")
+	cmd.PersistentFlags().DurationVar(&opts.minRemaining, "min-remaining", 15*time.Minute, "minimum remaining lifetime of cached credentials to reuse")
+	cmd.PersistentFlags().BoolVar(&opts.fetchAccountAlias, "fetch-account-alias", false, "save the alias of the AWS account by iam:ListAccountAliases")
+	cmd.PersistentFlags().BoolVar(&opts.verbose, "verbose", false, "print details to stderr")
+	cmd.Flags().BoolVar(&verify, "verify", false, "verify saved credentials by GetCallerIdentity")
 	cmd.PersistentFlags().BoolVarP(&web, "web", "w", false, "open AWS management console in a browser")
 	cmd.PersistentFlags().BoolVarP(&showVersion, "version", "v", false, "Show version")
 
+	cmd.AddCommand(newCredentialProcessCmd(&opts))
+	cmd.AddCommand(newExecCmd(&opts))
+	cmd.AddCommand(newEnvCmd(&opts))
+	cmd.AddCommand(newLoginCmd(&opts))
+	cmd.AddCommand(newProfilesCmd(&opts))
+	cmd.AddCommand(newListRolesCmd(&opts))
+	cmd.AddCommand(newStatusCmd(&opts))
+	cmd.AddCommand(newWhoamiCmd(&opts))
+	cmd.AddCommand(newLogoutCmd(&opts))
+	cmd.AddCommand(newMigrateExpirationCmd())
+	cmd.AddCommand(newServeCmd(&opts))
+	cmd.AddCommand(newIMDSCmd(&opts))
+	cmd.AddCommand(newAgentCmd(&opts))
+	cmd.AddCommand(newDaemonCmd(&opts))
+
 	return cmd
 }
 
@@ -134,26 +166,56 @@ func configureSettings(profile string) error {
 		cfg = config.Config{}
 	}
 
-	// Azure Tenant ID
-	var azureTenantIDOptions prompt.Options
-	if cfg.AzureTenantID != "" {
-		azureTenantIDOptions.Default = cfg.AzureTenantID
+	// Identity provider
+	var idpOptions prompt.Options
+	if cfg.IDP != "" {
+		idpOptions.Default = cfg.IDP
+	} else {
+		idpOptions.Default = config.IDPAzure
 	}
-	cfg.AzureTenantID, err = p.AskString("Azure Tenant ID", &azureTenantIDOptions)
+	idpOptions.ValidateFunc = func(val string) error {
+		for _, v := range config.IDPs {
+			if val == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("identity provider must be one of %s: %s", strings.Join(config.IDPs, ", "), val)
+	}
+	cfg.IDP, err = p.AskString(fmt.Sprintf("Identity Provider (%s)", strings.Join(config.IDPs, ", ")), &idpOptions)
 	if err != nil {
 		return err
 	}
 
-	// App ID URI
-	var appIDURIOptions prompt.Options
-	if cfg.AppIDURI != "" {
-		appIDURIOptions.Default = cfg.AppIDURI
+	switch cfg.IDP {
+	case config.IDPAzure:
+		err = configureAzureSettings(&p, &cfg)
+	case config.IDPOkta:
+		err = configureOktaSettings(&p, &cfg)
+	case config.IDPGeneric:
+		err = configureGenericSettings(&p, &cfg)
 	}
-	cfg.AppIDURI, err = p.AskString("App ID URI", &appIDURIOptions)
 	if err != nil {
 		return err
 	}
 
+	// Default role
+	var defaultRoleOptions prompt.Options
+	if cfg.RoleArn != "" {
+		defaultRoleOptions.Default = cfg.RoleArn
+	} else if cfg.RoleName != "" {
+		defaultRoleOptions.Default = cfg.RoleName
+	}
+	defaultRole, err := p.AskString("Default Role Name or ARN (optional, \"-\" to clear)", &defaultRoleOptions)
+	if err != nil {
+		return err
+	}
+	cfg.RoleArn, cfg.RoleName = "", ""
+	if strings.HasPrefix(defaultRole, "arn:") {
+		cfg.RoleArn = defaultRole
+	} else if defaultRole != "-" {
+		cfg.RoleName = defaultRole
+	}
+
 	// Default session duration hours
 	var defaultSessionDurationHoursOptions prompt.Options
 	if cfg.DefaultSessionDurationHours != 0 {
@@ -183,7 +245,140 @@ func configureSettings(profile string) error {
 		return err
 	}
 
-	return config.Save(cfg, profile)
+	// Credential store
+	var credentialStoreOptions prompt.Options
+	if cfg.CredentialStore != "" {
+		credentialStoreOptions.Default = cfg.CredentialStore
+	} else {
+		credentialStoreOptions.Default = config.CredentialStoreFile
+	}
+	credentialStoreOptions.ValidateFunc = func(val string) error {
+		for _, v := range config.CredentialStores {
+			if val == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("credential store must be one of %s: %s", strings.Join(config.CredentialStores, ", "), val)
+	}
+	cfg.CredentialStore, err = p.AskString(fmt.Sprintf("Credential Store (%s)", strings.Join(config.CredentialStores, ", ")), &credentialStoreOptions)
+	if err != nil {
+		return err
+	}
+
+	// credential_process
+	// Credentials in stores other than the file are only available via credential_process, so it is not asked.
+	useCredentialProcess := !usesFileStore(cfg)
+	if !useCredentialProcess {
+		var credentialProcessOptions prompt.Options
+		if cfg.CredentialProcess != "" {
+			credentialProcessOptions.Default = "y"
+		} else {
+			credentialProcessOptions.Default = "n"
+		}
+		useCredentialProcess, err = p.AskBool("Use credential_process to get credentials on demand (y/n)", &credentialProcessOptions)
+		if err != nil {
+			return err
+		}
+	}
+	if useCredentialProcess {
+		cfg.CredentialProcess, err = credentialProcessCommand(profile)
+		if err != nil {
+			return err
+		}
+	} else {
+		cfg.CredentialProcess = ""
+	}
+
+	err = config.Save(cfg, profile)
+	if err != nil {
+		return err
+	}
+
+	if !savesStaticKeys(cfg) {
+		// Static keys take precedence over credential_process, and must not be left after moving to another store.
+		return aws.DeleteCredentials(profile)
+	}
+	return nil
+}
+
+func configureAzureSettings(p *prompt.Prompt, cfg *config.Config) error {
+	var err error
+
+	// Azure Tenant ID
+	var azureTenantIDOptions prompt.Options
+	if cfg.AzureTenantID != "" {
+		azureTenantIDOptions.Default = cfg.AzureTenantID
+	}
+	cfg.AzureTenantID, err = p.AskString("Azure Tenant ID", &azureTenantIDOptions)
+	if err != nil {
+		return err
+	}
+
+	// App ID URI
+	var appIDURIOptions prompt.Options
+	if cfg.AppIDURI != "" {
+		appIDURIOptions.Default = cfg.AppIDURI
+	}
+	cfg.AppIDURI, err = p.AskString("App ID URI", &appIDURIOptions)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func configureOktaSettings(p *prompt.Prompt, cfg *config.Config) error {
+	var err error
+
+	// Okta org URL
+	var oktaOrgURLOptions prompt.Options
+	if cfg.OktaOrgURL != "" {
+		oktaOrgURLOptions.Default = cfg.OktaOrgURL
+	}
+	oktaOrgURLOptions.ValidateFunc = func(val string) error {
+		if !strings.HasPrefix(val, "https://") {
+			return fmt.Errorf("okta org URL must start with https://: %s", val)
+		}
+		return nil
+	}
+	cfg.OktaOrgURL, err = p.AskString("Okta Org URL (e.g. https://example.okta.com)", &oktaOrgURLOptions)
+	if err != nil {
+		return err
+	}
+
+	// Okta app embed path
+	var oktaAppEmbedPathOptions prompt.Options
+	if cfg.OktaAppEmbedPath != "" {
+		oktaAppEmbedPathOptions.Default = cfg.OktaAppEmbedPath
+	}
+	cfg.OktaAppEmbedPath, err = p.AskString("Okta App Embed Path (e.g. /home/amazon_aws/0oa1b2c3d4/272)", &oktaAppEmbedPathOptions)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func configureGenericSettings(p *prompt.Prompt, cfg *config.Config) error {
+	var err error
+
+	// SAML start URL
+	var samlStartURLOptions prompt.Options
+	if cfg.SAMLStartURL != "" {
+		samlStartURLOptions.Default = cfg.SAMLStartURL
+	}
+	samlStartURLOptions.ValidateFunc = func(val string) error {
+		if !strings.HasPrefix(val, "https://") && !strings.HasPrefix(val, "http://") {
+			return fmt.Errorf("SAML start URL must start with https:// or http://: %s", val)
+		}
+		return nil
+	}
+	cfg.SAMLStartURL, err = p.AskString("SAML Start URL (IdP-initiated login URL of the AWS app)", &samlStartURLOptions)
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
 func openBrowser() error {
@@ -218,13 +413,18 @@ func openBrowser() error {
 }
 
 func handleSignal(cancel context.CancelFunc) {
+	notifySignal(func(os.Signal) {
+		cancel()
+	})
+}
+
+func notifySignal(handler func(os.Signal)) {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
 
 	go func() {
 		for {
-			<-signalChan
-			cancel()
+			handler(<-signalChan)
 		}
 	}()
 }
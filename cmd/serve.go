@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const containerCredentialsPath = "/credentials"
+
+// containerCredentials is the response of the container credentials endpoint
+// ref: https://docs.aws.amazon.com/sdkref/latest/guide/feature-container-credentials.html
+type containerCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+	Expiration      string
+}
+
+func newServeCmd(opts *options) *cobra.Command {
+	var addr string
+	var shell string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve credentials by the container credentials endpoint of AWS SDKs",
+		Long: `Serve credentials of the profile by the container credentials endpoint of AWS SDKs, as Amazon ECS does.
+Credentials are refreshed in background when they are about to expire, and the current ones are served until then.
+Refreshes assume the role selected at the start without asking the user.
+It prints shell commands to set AWS_CONTAINER_CREDENTIALS_FULL_URI and AWS_CONTAINER_AUTHORIZATION_TOKEN,
+and serves until it is interrupted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			shell, err := resolveShell(shell)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			token, err := randomToken()
+			if err != nil {
+				return err
+			}
+
+			r := newRefresher(ctx, *opts)
+			_, err = r.Credentials(ctx)
+			if err != nil {
+				return err
+			}
+
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+
+			err = printEnvironmentVariables(cmd.OutOrStdout(), shell, []environmentVariable{
+				{name: "AWS_CONTAINER_CREDENTIALS_FULL_URI", value: fmt.Sprintf("http://%s%s", listener.Addr(), containerCredentialsPath)},
+				{name: "AWS_CONTAINER_AUTHORIZATION_TOKEN", value: token},
+			})
+			if err != nil {
+				listener.Close()
+				return err
+			}
+
+			return serveHTTP(ctx, listener, newContainerCredentialsHandler(r, token))
+		},
+	}
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:0", "address to listen on. AWS SDKs only accept loopback addresses")
+	cmd.Flags().StringVar(&shell, "shell", "", fmt.Sprintf("shell syntax (%s). Detected from $SHELL by default", strings.Join(shells, ", ")))
+
+	return cmd
+}
+
+func newContainerCredentialsHandler(r *refresher, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(containerCredentialsPath, func(w http.ResponseWriter, req *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte(token)) != 1 {
+			http.Error(w, "invalid authorization token", http.StatusUnauthorized)
+			return
+		}
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		credentials, err := r.Credentials(req.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(containerCredentials{
+			AccessKeyID:     *credentials.AccessKeyId,
+			SecretAccessKey: *credentials.SecretAccessKey,
+			Token:           *credentials.SessionToken,
+			Expiration:      credentials.Expiration.UTC().Format(time.RFC3339),
+		})
+	})
+	return mux
+}
+
+// serveHTTP serves HTTP requests on the listener until ctx is done
+func serveHTTP(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	err := server.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// randomToken returns a random hex string to authorize clients
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
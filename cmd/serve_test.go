@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerCredentialsHandler(t *testing.T) {
+	// setup
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	r := &refresher{credentials: &sts.Credentials{
+		AccessKeyId:     aws.String("access-key-id"),
+		SecretAccessKey: aws.String("secret-access-key"),
+		SessionToken:    aws.String("session-token"),
+		Expiration:      &expiration,
+	}}
+	handler := newContainerCredentialsHandler(r, "token")
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		wantStatus    int
+	}{
+		{
+			name:          "returns credentials",
+			method:        http.MethodGet,
+			path:          containerCredentialsPath,
+			authorization: "token",
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "rejects an invalid token",
+			method:        http.MethodGet,
+			path:          containerCredentialsPath,
+			authorization: "invalid",
+			wantStatus:    http.StatusUnauthorized,
+		},
+		{
+			name:       "rejects a request without a token",
+			method:     http.MethodGet,
+			path:       containerCredentialsPath,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "rejects other methods",
+			method:        http.MethodPost,
+			path:          containerCredentialsPath,
+			authorization: "token",
+			wantStatus:    http.StatusMethodNotAllowed,
+		},
+		{
+			name:          "returns not found for other paths",
+			method:        http.MethodGet,
+			path:          "/",
+			authorization: "token",
+			wantStatus:    http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// exercise
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			// verify
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var got containerCredentials
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, containerCredentials{
+				AccessKeyID:     "access-key-id",
+				SecretAccessKey: "secret-access-key",
+				Token:           "session-token",
+				Expiration:      expiration.Format(time.RFC3339),
+			}, got)
+		})
+	}
+}
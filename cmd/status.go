@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/cache"
+	"github.com/cybozu/assam/config"
+	"github.com/spf13/cobra"
+)
+
+// profileStatus is the status of a profile in the output of status subcommand
+type profileStatus struct {
+	Profile          string
+	Role             string     `json:",omitempty"`
+	Expiration       *time.Time `json:",omitempty"`
+	RemainingSeconds int64
+	Expired          bool
+}
+
+func newStatusCmd(opts *options) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Print profiles and the expiration of their credentials",
+		Long: `Print profiles configured by assam and the expiration of their credentials.
+Only the profile is printed when --profile is specified.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if output != outputTable && output != outputJSON {
+				return fmt.Errorf("output must be one of %s, %s: %s", outputTable, outputJSON, output)
+			}
+
+			profiles := []string{opts.profile}
+			if !cmd.Flags().Changed("profile") {
+				var err error
+				profiles, err = config.Profiles()
+				if err != nil {
+					return err
+				}
+			}
+
+			statuses := make([]profileStatus, len(profiles))
+			for i, profile := range profiles {
+				status, err := getProfileStatus(profile, time.Now())
+				if err != nil {
+					return err
+				}
+				statuses[i] = status
+			}
+
+			return printProfileStatuses(cmd.OutOrStdout(), output, statuses)
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", outputTable, fmt.Sprintf("output format (%s, %s)", outputTable, outputJSON))
+
+	return cmd
+}
+
+func getProfileStatus(profile string, now time.Time) (profileStatus, error) {
+	status := profileStatus{Profile: profile, Expired: true}
+
+	cfg := storeConfig(profile)
+	status.Role = cfg.RoleArn
+	if status.Role == "" {
+		status.Role = cfg.RoleName
+	}
+
+	entry, err := cache.Get(profile)
+	if err != nil {
+		return status, err
+	}
+	if status.Role == "" && entry != nil {
+		status.Role = entry.RoleArn
+	}
+
+	var expiration time.Time
+	var ok bool
+	if savesStaticKeys(cfg) {
+		expiration, ok, err = aws.LoadExpiration(profile)
+		if err != nil {
+			return status, err
+		}
+	} else if entry != nil && entry.Credentials.Expiration != nil {
+		// Credentials got via credential_process or kept in other stores are only cached.
+		expiration, ok = *entry.Credentials.Expiration, true
+	}
+	if ok {
+		status.Expiration = &expiration
+		if remaining := expiration.Sub(now); remaining > 0 {
+			status.RemainingSeconds = int64(remaining.Seconds())
+			status.Expired = false
+		}
+	}
+
+	return status, nil
+}
+
+func printProfileStatuses(w io.Writer, output string, statuses []profileStatus) error {
+	if output == outputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statuses)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, err := fmt.Fprintln(tw, "PROFILE\tROLE\tEXPIRATION\tREMAINING\tSTATUS")
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		expiration, remaining, state := "-", "-", "no credentials"
+		if status.Expiration != nil {
+			expiration = status.Expiration.Local().Format(time.RFC3339)
+			remaining = (time.Duration(status.RemainingSeconds) * time.Second).String()
+			state = "valid"
+			if status.Expired {
+				state = "expired"
+			}
+		}
+
+		role := status.Role
+		if role == "" {
+			role = "-"
+		}
+
+		_, err := fmt.Fprintln(tw, strings.Join([]string{status.Profile, role, expiration, remaining, state}, "\t"))
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
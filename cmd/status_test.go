@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProfileStatus(t *testing.T) {
+	// setup
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	err := os.WriteFile(filepath.Join(dir, "config"), []byte(`[profile dev]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+assam_role_name = Admin
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "credentials"), []byte(`[dev]
+aws_session_expiration = 2024-01-02 03:04:05 +0000 UTC
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiration := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("returns remaining lifetime of valid credentials", func(t *testing.T) {
+		got, err := getProfileStatus("dev", expiration.Add(-time.Hour))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Admin", got.Role)
+		assert.True(t, expiration.Equal(*got.Expiration))
+		assert.Equal(t, int64(3600), got.RemainingSeconds)
+		assert.False(t, got.Expired)
+	})
+
+	t.Run("returns expired credentials", func(t *testing.T) {
+		got, err := getProfileStatus("dev", expiration.Add(time.Second))
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), got.RemainingSeconds)
+		assert.True(t, got.Expired)
+	})
+
+	t.Run("returns a profile without credentials as expired", func(t *testing.T) {
+		got, err := getProfileStatus("stg", expiration)
+
+		assert.NoError(t, err)
+		assert.Nil(t, got.Expiration)
+		assert.True(t, got.Expired)
+	})
+}
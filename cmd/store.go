@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/config"
+)
+
+// passphraseEnv is the environment variable of the passphrase of the encrypted-file credential store
+const passphraseEnv = "ASSAM_PASSPHRASE"
+
+// newCredentialStore returns the credential store of the profile
+func newCredentialStore(cfg config.Config) (aws.CredentialStore, error) {
+	switch cfg.CredentialStore {
+	case config.CredentialStoreFile, "":
+		return aws.NewFileStore(), nil
+	case config.CredentialStoreKeyring:
+		return aws.NewKeyringStore(), nil
+	case config.CredentialStoreEncryptedFile:
+		passphrase := os.Getenv(passphraseEnv)
+		if passphrase == "" {
+			return nil, fmt.Errorf("%s must be set to use the %s credential store", passphraseEnv, cfg.CredentialStore)
+		}
+		return aws.NewEncryptedFileStore(passphrase), nil
+	default:
+		return nil, fmt.Errorf("unsupported credential store: %s", cfg.CredentialStore)
+	}
+}
+
+// deleteStoredCredentials deletes credentials of the profile from its credential store.
+// Deleting an encrypted file does not need the passphrase, so that logout works without it.
+func deleteStoredCredentials(profile string, cfg config.Config) error {
+	var store aws.CredentialStore
+	if cfg.CredentialStore == config.CredentialStoreEncryptedFile {
+		store = aws.NewEncryptedFileStore("")
+	} else {
+		var err error
+		store, err = newCredentialStore(cfg)
+		if err != nil {
+			return err
+		}
+	}
+	return store.Delete(profile)
+}
+
+// storeConfig returns config of the profile to find its credential store.
+// Profiles not configured by assam use AWS credentials file.
+func storeConfig(profile string) config.Config {
+	cfg, err := config.NewConfig(profile)
+	if err != nil {
+		return config.Config{CredentialStore: config.CredentialStoreFile}
+	}
+	return cfg
+}
+
+// usesFileStore reports whether credentials of the profile are saved in AWS credentials file
+func usesFileStore(cfg config.Config) bool {
+	return cfg.CredentialStore == config.CredentialStoreFile || cfg.CredentialStore == ""
+}
+
+// savesStaticKeys reports whether AWS SDKs and CLI read credentials of the profile from AWS credentials file
+func savesStaticKeys(cfg config.Config) bool {
+	return cfg.CredentialProcess == "" && usesFileStore(cfg)
+}
+
+// saveCredentials saves credentials of the profile to AWS credentials file.
+// Credentials in other stores have been saved along with the cache, and AWS SDKs and CLI get them via credential_process.
+// Static keys of such profiles are deleted instead,
+// because AWS SDKs and CLI prefer them to credential_process even after they expire.
+func saveCredentials(profile string, cfg config.Config, credentials sts.Credentials) error {
+	if !savesStaticKeys(cfg) {
+		return aws.DeleteCredentials(profile)
+	}
+	return aws.SaveCredentials(profile, credentials)
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/cache"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newWhoamiCmd(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "whoami",
+		Short: "Verify credentials of the profile by GetCallerIdentity",
+		Long: `Verify credentials of the profile in its credential store by sts:GetCallerIdentity.
+It exits with non-zero status when the credentials are invalid or expired.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			handleSignal(cancel)
+
+			return verifyCredentials(ctx, cmd.OutOrStdout(), opts.profile)
+		},
+	}
+}
+
+// verifyCredentials prints the identity of credentials of the profile, or returns an error if they do not work
+func verifyCredentials(ctx context.Context, w io.Writer, profile string) error {
+	credentials, err := loadSavedCredentials(profile)
+	if err != nil {
+		return err
+	}
+	if credentials == nil {
+		return fmt.Errorf("no credentials of profile %s", profile)
+	}
+	expiration := credentials.Expiration
+	if expiration != nil && !expiration.After(time.Now()) {
+		return fmt.Errorf("credentials of profile %s expired at %s", profile, expiration.Local())
+	}
+
+	identity, err := aws.GetCallerIdentity(ctx, *credentials)
+	if err != nil {
+		return errors.Wrapf(err, "credentials of profile %s are invalid", profile)
+	}
+
+	fmt.Fprintf(w, "Arn:       %s\n", *identity.Arn)
+	fmt.Fprintf(w, "Account:   %s\n", *identity.Account)
+	fmt.Fprintf(w, "Session:   %s\n", aws.SessionName(*identity.Arn))
+	if expiration != nil {
+		fmt.Fprintf(w, "Remaining: %s\n", time.Until(*expiration).Truncate(time.Second))
+	}
+
+	return nil
+}
+
+// loadSavedCredentials returns credentials of the profile in its credential store, or in the cache
+// for profiles with credential_process. It returns nil without an error when no credentials are saved.
+func loadSavedCredentials(profile string) (*sts.Credentials, error) {
+	cfg := storeConfig(profile)
+	store, err := newCredentialStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := store.Load(profile)
+	if err != nil || credentials != nil || savesStaticKeys(cfg) {
+		return credentials, err
+	}
+
+	entry, err := cache.Get(profile)
+	if err != nil || entry == nil || entry.Credentials.AccessKeyId == nil {
+		return nil, err
+	}
+	return &entry.Credentials, nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/cybozu/assam/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSavedCredentials(t *testing.T) {
+	// setup
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	err := os.WriteFile(filepath.Join(dir, "config"), []byte(`[profile dev]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+
+[profile process]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+credential_process = assam credential-process --profile process
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "credentials"), []byte(`[dev]
+aws_access_key_id = file-access-key-id
+aws_secret_access_key = secret-access-key
+aws_session_token = session-token
+aws_session_expiration = 2024-01-02T03:04:05Z
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, profile := range []string{"dev", "process"} {
+		err = cache.Save(profile, cache.Entry{
+			RoleArn: "arn:aws:iam::012345678901:role/Admin",
+			Credentials: sts.Credentials{
+				AccessKeyId:     aws.String("cached-access-key-id"),
+				SecretAccessKey: aws.String("secret-access-key"),
+				SessionToken:    aws.String("session-token"),
+				Expiration:      aws.Time(time.Now().Add(time.Hour)),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("loads credentials from the credentials file", func(t *testing.T) {
+		got, err := loadSavedCredentials("dev")
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, "file-access-key-id", *got.AccessKeyId)
+		}
+	})
+
+	t.Run("loads credentials of credential_process from the cache", func(t *testing.T) {
+		got, err := loadSavedCredentials("process")
+
+		assert.NoError(t, err)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, "cached-access-key-id", *got.AccessKeyId)
+		}
+	})
+
+	t.Run("returns nil without credentials", func(t *testing.T) {
+		got, err := loadSavedCredentials("stg")
+
+		assert.NoError(t, err)
+		assert.Nil(t, got)
+	})
+
+	t.Run("returns an error for expired credentials without calling AWS", func(t *testing.T) {
+		err := verifyCredentials(context.Background(), io.Discard, "dev")
+
+		assert.EqualError(t, err, "credentials of profile dev expired at "+time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Local().String())
+	})
+
+	t.Run("returns an error without credentials", func(t *testing.T) {
+		err := verifyCredentials(context.Background(), io.Discard, "stg")
+
+		assert.EqualError(t, err, "no credentials of profile stg")
+	})
+}
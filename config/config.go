@@ -4,25 +4,83 @@ package config
 import (
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/cybozu/assam/inifile"
 	"gopkg.in/ini.v1"
 	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
 )
 
+// Identity providers supported by assam.
+const (
+	IDPAzure   = "azure"
+	IDPOkta    = "okta"
+	IDPGeneric = "generic"
+)
+
+// IDPs is the list of supported identity providers.
+var IDPs = []string{IDPAzure, IDPOkta, IDPGeneric}
+
+// Credential stores supported by assam.
+const (
+	CredentialStoreFile          = "file"
+	CredentialStoreKeyring       = "keyring"
+	CredentialStoreEncryptedFile = "encrypted-file"
+)
+
+// CredentialStores is the list of supported credential stores.
+var CredentialStores = []string{CredentialStoreFile, CredentialStoreKeyring, CredentialStoreEncryptedFile}
+
 // Config is this tool's configuration
 type Config struct {
+	IDP                         string
 	AppIDURI                    string
 	AzureTenantID               string
+	OktaOrgURL                  string
+	OktaAppEmbedPath            string
+	SAMLStartURL                string
 	DefaultSessionDurationHours int
 	ChromeUserDataDir           string
+	// CredentialProcess is the credential_process setting of AWS SDKs and CLI. Empty if not used.
+	CredentialProcess string
+	// RoleArn is the default role of the profile. Empty if not specified.
+	RoleArn string
+	// RoleName is the default role name of the profile. Ignored if RoleArn is specified.
+	RoleName string
+	// RoleChain is the roles assumed in order after AssumeRoleWithSAML.
+	RoleChain []ChainedRole
+	// CredentialStore is where credentials of the profile are saved.
+	CredentialStore string
+}
+
+// ChainedRole is a role assumed by sts:AssumeRole with credentials of the previous role
+type ChainedRole struct {
+	RoleArn    string
+	ExternalID string
 }
 
 const (
+	idpKeyName                         = "idp"
 	appIDURIKeyName                    = "app_id_uri"
 	azureTenantIDKeyName               = "azure_tenant_id"
+	oktaOrgURLKeyName                  = "okta_org_url"
+	oktaAppEmbedPathKeyName            = "okta_app_embed_path"
+	samlStartURLKeyName                = "saml_start_url"
 	defaultSessionDurationHoursKeyName = "default_session_duration_hours"
 	chromeUserDataDirKeyName           = "chrome_user_data_dir"
+	credentialProcessKeyName           = "credential_process"
+	roleChainKeyName                   = "role_chain"
+	roleArnKeyName                     = "assam_role_arn"
+	roleNameKeyName                    = "assam_role_name"
+	credentialStoreKeyName             = "credential_store"
+	groupProfilesKeyName               = "profiles"
+	accountAliasesSectionName          = "assam-account-aliases"
+	// Keys of the default role saved by old versions, which are renamed to roleArnKeyName and roleNameKeyName.
+	// AWS SDKs and CLI read role_arn as the role of an assume-role profile.
+	legacyRoleArnKeyName    = "role_arn"
+	legacyRoleNameKeyName   = "role_name"
+	sourceProfileKeyName    = "source_profile"
+	credentialSourceKeyName = "credential_source"
 )
 
 // NewConfig returns Config from default AWS config file
@@ -39,14 +97,45 @@ func NewConfig(profile string) (Config, error) {
 		return cfg, err
 	}
 
-	appIDURIKey, err := section.GetKey(appIDURIKeyName)
-	if err != nil {
-		return cfg, err
-	}
+	// Profiles configured before other IdPs were supported have no idp key.
+	cfg.IDP = section.Key(idpKeyName).MustString(IDPAzure)
 
-	azureTenantIDKey, err := section.GetKey(azureTenantIDKeyName)
-	if err != nil {
-		return cfg, err
+	switch cfg.IDP {
+	case IDPAzure:
+		appIDURIKey, err := section.GetKey(appIDURIKeyName)
+		if err != nil {
+			return cfg, err
+		}
+
+		azureTenantIDKey, err := section.GetKey(azureTenantIDKeyName)
+		if err != nil {
+			return cfg, err
+		}
+
+		cfg.AppIDURI = appIDURIKey.Value()
+		cfg.AzureTenantID = azureTenantIDKey.Value()
+	case IDPOkta:
+		oktaOrgURLKey, err := section.GetKey(oktaOrgURLKeyName)
+		if err != nil {
+			return cfg, err
+		}
+
+		oktaAppEmbedPathKey, err := section.GetKey(oktaAppEmbedPathKeyName)
+		if err != nil {
+			return cfg, err
+		}
+
+		cfg.OktaOrgURL = oktaOrgURLKey.Value()
+		cfg.OktaAppEmbedPath = oktaAppEmbedPathKey.Value()
+	case IDPGeneric:
+		samlStartURLKey, err := section.GetKey(samlStartURLKeyName)
+		if err != nil {
+			return cfg, err
+		}
+
+		cfg.SAMLStartURL = samlStartURLKey.Value()
+	default:
+		return cfg, fmt.Errorf("unsupported identity provider: %s", cfg.IDP)
 	}
 
 	defaultSessionDurationHoursKey, err := section.GetKey(defaultSessionDurationHoursKeyName)
@@ -59,40 +148,253 @@ func NewConfig(profile string) (Config, error) {
 		return cfg, err
 	}
 
-	cfg.AppIDURI = appIDURIKey.Value()
-	cfg.AzureTenantID = azureTenantIDKey.Value()
 	defaultSessionDurationHours, err := strconv.Atoi(defaultSessionDurationHoursKey.Value())
 	if err != nil {
 		return cfg, err
 	}
 	cfg.DefaultSessionDurationHours = defaultSessionDurationHours
 	cfg.ChromeUserDataDir = userDataDirKey.Value()
+	cfg.CredentialProcess = section.Key(credentialProcessKeyName).String()
+	cfg.RoleArn = section.Key(roleArnKeyName).String()
+	cfg.RoleName = section.Key(roleNameKeyName).String()
+	if hasLegacyRoleArn(section) || section.HasKey(legacyRoleNameKeyName) {
+		if cfg.RoleArn == "" && hasLegacyRoleArn(section) {
+			cfg.RoleArn = section.Key(legacyRoleArnKeyName).String()
+		}
+		if cfg.RoleName == "" {
+			cfg.RoleName = section.Key(legacyRoleNameKeyName).String()
+		}
+		err = migrateRoleKeys(profile)
+		if err != nil {
+			return cfg, err
+		}
+	}
+	cfg.RoleChain, err = parseRoleChain(section.Key(roleChainKeyName).String())
+	if err != nil {
+		return cfg, err
+	}
+	cfg.CredentialStore = section.Key(credentialStoreKeyName).MustString(CredentialStoreFile)
+	if !contains(CredentialStores, cfg.CredentialStore) {
+		return cfg, fmt.Errorf("unsupported credential store: %s", cfg.CredentialStore)
+	}
 
 	return cfg, nil
 }
 
 // Save saves config to file.
 func Save(cfg Config, profile string) error {
+	return updateConfigFile(func(f *ini.File) error {
+		section := f.Section(sectionName(profile))
+
+		section.Key(idpKeyName).SetValue(cfg.IDP)
+		switch cfg.IDP {
+		case IDPAzure:
+			section.Key(appIDURIKeyName).SetValue(cfg.AppIDURI)
+			section.Key(azureTenantIDKeyName).SetValue(cfg.AzureTenantID)
+		case IDPOkta:
+			section.Key(oktaOrgURLKeyName).SetValue(cfg.OktaOrgURL)
+			section.Key(oktaAppEmbedPathKeyName).SetValue(cfg.OktaAppEmbedPath)
+		case IDPGeneric:
+			section.Key(samlStartURLKeyName).SetValue(cfg.SAMLStartURL)
+		}
+		section.Key(defaultSessionDurationHoursKeyName).SetValue(strconv.Itoa(cfg.DefaultSessionDurationHours))
+		section.Key(chromeUserDataDirKeyName).SetValue(cfg.ChromeUserDataDir)
+		if cfg.CredentialProcess != "" {
+			section.Key(credentialProcessKeyName).SetValue(cfg.CredentialProcess)
+		} else {
+			section.DeleteKey(credentialProcessKeyName)
+		}
+		if cfg.RoleArn != "" {
+			section.Key(roleArnKeyName).SetValue(cfg.RoleArn)
+		} else {
+			section.DeleteKey(roleArnKeyName)
+		}
+		if cfg.RoleName != "" {
+			section.Key(roleNameKeyName).SetValue(cfg.RoleName)
+		} else {
+			section.DeleteKey(roleNameKeyName)
+		}
+		if hasLegacyRoleArn(section) {
+			section.DeleteKey(legacyRoleArnKeyName)
+		}
+		section.DeleteKey(legacyRoleNameKeyName)
+		if len(cfg.RoleChain) != 0 {
+			section.Key(roleChainKeyName).SetValue(formatRoleChain(cfg.RoleChain))
+		} else {
+			section.DeleteKey(roleChainKeyName)
+		}
+		if cfg.CredentialStore != "" && cfg.CredentialStore != CredentialStoreFile {
+			section.Key(credentialStoreKeyName).SetValue(cfg.CredentialStore)
+		} else {
+			section.DeleteKey(credentialStoreKeyName)
+		}
+
+		return nil
+	})
+}
+
+// SameIdentityProvider reports whether both configs authenticate with the same IdP settings,
+// so that a SAML response for one can be used for the other.
+func (c Config) SameIdentityProvider(other Config) bool {
+	return c.IDP == other.IDP &&
+		c.AppIDURI == other.AppIDURI &&
+		c.AzureTenantID == other.AzureTenantID &&
+		c.OktaOrgURL == other.OktaOrgURL &&
+		c.OktaAppEmbedPath == other.OktaAppEmbedPath &&
+		c.SAMLStartURL == other.SAMLStartURL &&
+		c.ChromeUserDataDir == other.ChromeUserDataDir
+}
+
+// Profiles returns profiles configured by assam from default AWS config file.
+func Profiles() ([]string, error) {
 	f, err := loadConfigFile()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	section := f.Section(sectionName(profile))
+	var profiles []string
+	for _, section := range f.Sections() {
+		if !section.HasKey(chromeUserDataDirKeyName) {
+			continue
+		}
 
-	section.Key(appIDURIKeyName).SetValue(cfg.AppIDURI)
-	section.Key(azureTenantIDKeyName).SetValue(cfg.AzureTenantID)
-	section.Key(defaultSessionDurationHoursKeyName).SetValue(strconv.Itoa(cfg.DefaultSessionDurationHours))
-	section.Key(chromeUserDataDirKeyName).SetValue(cfg.ChromeUserDataDir)
+		name := section.Name()
+		if name != "default" {
+			name = strings.TrimPrefix(name, "profile ")
+		}
+		profiles = append(profiles, name)
+	}
+	return profiles, nil
+}
 
-	file := getConfigFilename()
-	dir := filepath.Dir(file)
-	err = os.MkdirAll(dir, os.FileMode(0755))
+// GroupProfiles returns profiles of the profile group from default AWS config file.
+//
+//	[assam-group NAME]
+//	profiles = dev,stg,prod
+func GroupProfiles(group string) ([]string, error) {
+	f, err := loadConfigFile()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return f.SaveTo(file)
+	section, err := f.GetSection(groupSectionName(group))
+	if err != nil {
+		return nil, err
+	}
+
+	profilesKey, err := section.GetKey(groupProfilesKeyName)
+	if err != nil {
+		return nil, err
+	}
+
+	return profilesKey.Strings(","), nil
+}
+
+// AccountAliases returns friendly names of AWS accounts keyed by account ID from default AWS config file.
+//
+//	[assam-account-aliases]
+//	012345678901 = payments-prod
+func AccountAliases() (map[string]string, error) {
+	f, err := loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := map[string]string{}
+	section, err := f.GetSection(accountAliasesSectionName)
+	if err != nil {
+		// No aliases are defined.
+		return aliases, nil
+	}
+
+	for _, key := range section.Keys() {
+		aliases[key.Name()] = key.Value()
+	}
+	return aliases, nil
+}
+
+// SaveAccountAlias saves the friendly name of the AWS account to file.
+func SaveAccountAlias(accountID string, alias string) error {
+	return updateConfigFile(func(f *ini.File) error {
+		f.Section(accountAliasesSectionName).Key(accountID).SetValue(alias)
+		return nil
+	})
+}
+
+// hasLegacyRoleArn reports whether the section has the default role saved by old versions.
+// role_arn with source_profile or credential_source is the assume-role setting of AWS SDKs and CLI.
+func hasLegacyRoleArn(section *ini.Section) bool {
+	return section.HasKey(legacyRoleArnKeyName) && !section.HasKey(sourceProfileKeyName) && !section.HasKey(credentialSourceKeyName)
+}
+
+// migrateRoleKeys renames keys of the default role of the profile saved by old versions, so that AWS SDKs and CLI can use the profile
+func migrateRoleKeys(profile string) error {
+	return updateConfigFile(func(f *ini.File) error {
+		section := f.Section(sectionName(profile))
+		migrated := false
+		if hasLegacyRoleArn(section) {
+			if !section.HasKey(roleArnKeyName) {
+				section.Key(roleArnKeyName).SetValue(section.Key(legacyRoleArnKeyName).String())
+			}
+			section.DeleteKey(legacyRoleArnKeyName)
+			migrated = true
+		}
+		if section.HasKey(legacyRoleNameKeyName) {
+			if !section.HasKey(roleNameKeyName) {
+				section.Key(roleNameKeyName).SetValue(section.Key(legacyRoleNameKeyName).String())
+			}
+			section.DeleteKey(legacyRoleNameKeyName)
+			migrated = true
+		}
+		if !migrated {
+			return inifile.SkipSave
+		}
+		return nil
+	})
+}
+
+// parseRoleChain parses the comma separated roles. Each role is a role ARN optionally followed by "|" and an external ID.
+//
+//	e.g. arn:aws:iam::111122223333:role/Hub,arn:aws:iam::444455556666:role/Workload|external-id
+func parseRoleChain(value string) ([]ChainedRole, error) {
+	var chain []ChainedRole
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		s := strings.SplitN(v, "|", 2)
+		role := ChainedRole{RoleArn: strings.TrimSpace(s[0])}
+		if len(s) == 2 {
+			role.ExternalID = strings.TrimSpace(s[1])
+		}
+		if !strings.HasPrefix(role.RoleArn, "arn:") {
+			return nil, fmt.Errorf("invalid role ARN in %s: %s", roleChainKeyName, role.RoleArn)
+		}
+		chain = append(chain, role)
+	}
+	return chain, nil
+}
+
+func formatRoleChain(chain []ChainedRole) string {
+	values := make([]string, len(chain))
+	for i, role := range chain {
+		values[i] = role.RoleArn
+		if role.ExternalID != "" {
+			values[i] += "|" + role.ExternalID
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 func getConfigFilename() string {
@@ -109,6 +411,14 @@ func loadConfigFile() (*ini.File, error) {
 	return ini.LooseLoad(file)
 }
 
+func updateConfigFile(update func(f *ini.File) error) error {
+	return inifile.Update(getConfigFilename(), os.FileMode(0644), update)
+}
+
+func groupSectionName(group string) string {
+	return fmt.Sprintf("assam-group %s", group)
+}
+
 func sectionName(profile string) string {
 	if profile == "default" {
 		return profile
@@ -0,0 +1,288 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/ini.v1"
+)
+
+func TestParseRoleChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []ChainedRole
+		wantErr bool
+	}{
+		{
+			name:  "returns nil when value is empty",
+			value: "",
+			want:  nil,
+		},
+		{
+			name:  "parses roles with and without external ID",
+			value: "arn:aws:iam::111122223333:role/Hub, arn:aws:iam::444455556666:role/Workload|external-id",
+			want: []ChainedRole{
+				{RoleArn: "arn:aws:iam::111122223333:role/Hub"},
+				{RoleArn: "arn:aws:iam::444455556666:role/Workload", ExternalID: "external-id"},
+			},
+		},
+		{
+			name:    "returns an error when role ARN is invalid",
+			value:   "Workload",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRoleChain(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseRoleChain() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatRoleChain(t *testing.T) {
+	chain := []ChainedRole{
+		{RoleArn: "arn:aws:iam::111122223333:role/Hub"},
+		{RoleArn: "arn:aws:iam::444455556666:role/Workload", ExternalID: "external-id"},
+	}
+
+	assert.Equal(t, "arn:aws:iam::111122223333:role/Hub,arn:aws:iam::444455556666:role/Workload|external-id", formatRoleChain(chain))
+}
+
+func TestNewConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	err := os.WriteFile(filepath.Join(dir, "config"), []byte(`[profile legacy]
+app_id_uri = https://signin.aws.amazon.com/saml
+azure_tenant_id = tenant
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+
+[profile okta]
+idp = okta
+okta_org_url = https://example.okta.com
+okta_app_embed_path = /home/amazon_aws/0oa1b2c3d4/272
+default_session_duration_hours = 2
+chrome_user_data_dir = /tmp/assam
+
+[profile generic]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 3
+chrome_user_data_dir = /tmp/assam
+
+[profile missing]
+idp = okta
+okta_org_url = https://example.okta.com
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+
+[profile unknown]
+idp = unknown
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		profile string
+		want    Config
+		wantErr bool
+	}{
+		{
+			name:    "loads a profile without idp as Azure",
+			profile: "legacy",
+			want: Config{
+				IDP:                         IDPAzure,
+				AppIDURI:                    "https://signin.aws.amazon.com/saml",
+				AzureTenantID:               "tenant",
+				DefaultSessionDurationHours: 1,
+				ChromeUserDataDir:           "/tmp/assam",
+				CredentialStore:             CredentialStoreFile,
+			},
+		},
+		{
+			name:    "loads an Okta profile",
+			profile: "okta",
+			want: Config{
+				IDP:                         IDPOkta,
+				OktaOrgURL:                  "https://example.okta.com",
+				OktaAppEmbedPath:            "/home/amazon_aws/0oa1b2c3d4/272",
+				DefaultSessionDurationHours: 2,
+				ChromeUserDataDir:           "/tmp/assam",
+				CredentialStore:             CredentialStoreFile,
+			},
+		},
+		{
+			name:    "loads a generic profile",
+			profile: "generic",
+			want: Config{
+				IDP:                         IDPGeneric,
+				SAMLStartURL:                "https://idp.example.com/start",
+				DefaultSessionDurationHours: 3,
+				ChromeUserDataDir:           "/tmp/assam",
+				CredentialStore:             CredentialStoreFile,
+			},
+		},
+		{
+			name:    "returns an error when a setting of the IdP is missing",
+			profile: "missing",
+			wantErr: true,
+		},
+		{
+			name:    "returns an error for unsupported IdP",
+			profile: "unknown",
+			wantErr: true,
+		},
+		{
+			name:    "returns an error for undefined profile",
+			profile: "undefined",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConfig(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewConfigMigratesRoleKeys(t *testing.T) {
+	// setup
+	file := filepath.Join(t.TempDir(), "config")
+	t.Setenv("AWS_CONFIG_FILE", file)
+	err := os.WriteFile(file, []byte(`[profile dev]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+role_arn = arn:aws:iam::012345678901:role/Admin
+
+[profile chained]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+role_arn = arn:aws:iam::012345678901:role/Other
+source_profile = dev
+
+[profile named]
+idp = generic
+saml_start_url = https://idp.example.com/start
+default_session_duration_hours = 1
+chrome_user_data_dir = /tmp/assam
+role_name = Admin
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("renames role_arn saved by old versions", func(t *testing.T) {
+		// exercise
+		got, err := NewConfig("dev")
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, "arn:aws:iam::012345678901:role/Admin", got.RoleArn)
+		f, err := ini.Load(file)
+		if assert.NoError(t, err) {
+			section := f.Section("profile dev")
+			assert.False(t, section.HasKey("role_arn"))
+			assert.Equal(t, "arn:aws:iam::012345678901:role/Admin", section.Key("assam_role_arn").String())
+		}
+	})
+
+	t.Run("keeps role_arn of assume-role profiles", func(t *testing.T) {
+		// exercise
+		got, err := NewConfig("chained")
+
+		// verify
+		assert.NoError(t, err)
+		assert.Empty(t, got.RoleArn)
+		f, err := ini.Load(file)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "arn:aws:iam::012345678901:role/Other", f.Section("profile chained").Key("role_arn").String())
+		}
+	})
+
+	t.Run("renames role_name saved by old versions", func(t *testing.T) {
+		// exercise
+		got, err := NewConfig("named")
+
+		// verify
+		assert.NoError(t, err)
+		assert.Equal(t, "Admin", got.RoleName)
+		f, err := ini.Load(file)
+		if assert.NoError(t, err) {
+			section := f.Section("profile named")
+			assert.False(t, section.HasKey("role_name"))
+			assert.Equal(t, "Admin", section.Key("assam_role_name").String())
+		}
+	})
+}
+
+func TestSaveAndNewConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{
+			name: "role ARN",
+			cfg:  Config{RoleArn: "arn:aws:iam::012345678901:role/Admin"},
+		},
+		{
+			name: "role name",
+			cfg:  Config{RoleName: "path/Admin"},
+		},
+		{
+			name: "no role",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// setup
+			file := filepath.Join(t.TempDir(), "config")
+			t.Setenv("AWS_CONFIG_FILE", file)
+			cfg := tt.cfg
+			cfg.IDP = IDPGeneric
+			cfg.SAMLStartURL = "https://idp.example.com/start"
+			cfg.DefaultSessionDurationHours = 1
+			cfg.ChromeUserDataDir = "/tmp/assam"
+			cfg.CredentialStore = CredentialStoreFile
+
+			// exercise
+			err := Save(cfg, "dev")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewConfig("dev")
+
+			// verify
+			assert.NoError(t, err)
+			assert.Equal(t, cfg, got)
+			f, err := ini.Load(file)
+			if assert.NoError(t, err) {
+				// AWS SDKs and CLI would assume role_arn with the credentials of the profile.
+				assert.False(t, f.Section("profile dev").HasKey("role_arn"))
+				assert.False(t, f.Section("profile dev").HasKey("role_name"))
+			}
+		})
+	}
+}
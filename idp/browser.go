@@ -0,0 +1,162 @@
+package idp
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/cybozu/assam/aws"
+	"github.com/pkg/errors"
+)
+
+// browser drives Chrome and captures the SAML response which an IdP posts to AWS
+type browser struct {
+	msgChan chan *network.EventRequestWillBeSent
+}
+
+func newBrowser() browser {
+	return browser{
+		msgChan: make(chan *network.EventRequestWillBeSent),
+	}
+}
+
+// authenticate opens loginURL and waits until the SAML response is posted to AWS.
+// It opens a tab in Chrome kept by BrowserPool of ctx if exists, otherwise it starts Chrome.
+func (b *browser) authenticate(ctx context.Context, userDataDir string, loginURL string) (string, error) {
+	if pool := browserPoolFrom(ctx); pool != nil {
+		tabCtx, cancel, err := pool.newTab(ctx, userDataDir)
+		if err != nil {
+			return "", err
+		}
+		defer cancel()
+
+		return b.authenticateInTab(tabCtx, loginURL)
+	}
+
+	if settings, ok := headlessSettingsFrom(ctx); ok {
+		response, err := b.authenticateInChrome(ctx, userDataDir, loginURL, true, settings.timeout)
+		if err == nil || ctx.Err() != nil {
+			return response, err
+		}
+		// The IdP requires interaction such as entering a password or MFA.
+		return b.authenticateInChrome(ctx, userDataDir, loginURL, false, settings.interactionTimeout)
+	}
+
+	return b.authenticateInChrome(ctx, userDataDir, loginURL, false, 0)
+}
+
+// authenticateInChrome starts Chrome and authenticates in it. It gives up after timeout unless timeout is 0.
+func (b *browser) authenticateInChrome(ctx context.Context, userDataDir string, loginURL string, headless bool, timeout time.Duration) (string, error) {
+	chromeCtx, cancel := newChromeContext(ctx, userDataDir, headless)
+	defer cancel()
+
+	tabCtx := chromeCtx
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		tabCtx, cancelTimeout = context.WithTimeout(chromeCtx, timeout)
+		defer cancelTimeout()
+	}
+
+	response, err := b.authenticateInTab(tabCtx, loginURL)
+
+	// Shut down gracefully to ensure that user data is stored and the user data directory is released.
+	cancelErr := chromedp.Cancel(chromeCtx)
+	if err != nil {
+		return "", err
+	}
+	if cancelErr != nil {
+		return "", cancelErr
+	}
+
+	return response, nil
+}
+
+// authenticateInTab opens loginURL in the tab of ctx and waits until the SAML response is posted to AWS
+func (b *browser) authenticateInTab(ctx context.Context, loginURL string) (string, error) {
+	// Need network.Enable() to handle network events.
+	err := chromedp.Run(ctx, network.Enable())
+	if err != nil {
+		return "", err
+	}
+
+	b.listenNetworkRequest(ctx)
+
+	err = chromedp.Run(ctx, chromedp.Navigate(loginURL))
+	if err != nil {
+		return "", err
+	}
+
+	return b.fetchSAMLResponse(ctx)
+}
+
+// newChromeContext returns a context of Chrome which stores user data in userDataDir
+func newChromeContext(ctx context.Context, userDataDir string, headless bool) (context.Context, context.CancelFunc) {
+	// Need to expand environment variables because chromedp does not expand.
+	expandedDir := os.ExpandEnv(userDataDir)
+
+	opts := []chromedp.ExecAllocatorOption{
+		chromedp.UserDataDir(expandedDir),
+		chromedp.NoFirstRun,
+		chromedp.NoDefaultBrowserCheck,
+	}
+	if headless {
+		opts = append(opts, chromedp.Headless)
+	}
+
+	allocContext, _ := chromedp.NewExecAllocator(ctx, opts...)
+
+	return chromedp.NewContext(allocContext)
+}
+
+func (b *browser) listenNetworkRequest(ctx context.Context) {
+	chromedp.ListenTarget(ctx, func(v interface{}) {
+		go func() {
+			if req, ok := v.(*network.EventRequestWillBeSent); ok {
+				select {
+				case b.msgChan <- req:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	})
+}
+
+func (b *browser) fetchSAMLResponse(ctx context.Context) (string, error) {
+	for {
+		var req *network.EventRequestWillBeSent
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case req = <-b.msgChan:
+		}
+
+		if req.Request.URL != aws.EndpointURL {
+			continue
+		}
+
+		var postData string
+		for p := range req.Request.PostDataEntries {
+			postData += req.Request.PostDataEntries[p].Bytes
+		}
+		d, err := base64.StdEncoding.DecodeString(postData)
+		if err != nil {
+			return "", err
+		}
+
+		form, err := url.ParseQuery(string(d))
+		if err != nil {
+			return "", err
+		}
+
+		samlResponse, ok := form["SAMLResponse"]
+		if !ok || len(samlResponse) == 0 {
+			return "", errors.New("no such key: SAMLResponse")
+		}
+
+		return samlResponse[0], nil
+	}
+}
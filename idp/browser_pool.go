@@ -0,0 +1,102 @@
+package idp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+)
+
+type browserPoolKey struct{}
+
+// BrowserPool keeps Chrome running per user data directory,
+// so that authentications open a tab without waiting for Chrome to start
+type BrowserPool struct {
+	ctx context.Context
+
+	mu       sync.Mutex
+	browsers map[string]context.Context
+	cancels  []context.CancelFunc
+}
+
+// NewBrowserPool returns BrowserPool. Chrome is started on the first authentication with each user data directory.
+func NewBrowserPool(ctx context.Context) *BrowserPool {
+	return &BrowserPool{
+		ctx:      ctx,
+		browsers: map[string]context.Context{},
+	}
+}
+
+// WithBrowserPool returns a context which makes authentications use Chrome kept by the pool
+func WithBrowserPool(ctx context.Context, pool *BrowserPool) context.Context {
+	return context.WithValue(ctx, browserPoolKey{}, pool)
+}
+
+func browserPoolFrom(ctx context.Context) *BrowserPool {
+	pool, _ := ctx.Value(browserPoolKey{}).(*BrowserPool)
+	return pool
+}
+
+// Close shuts down Chrome gracefully to ensure that user data is stored
+func (p *BrowserPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, ctx := range p.browsers {
+		_ = chromedp.Cancel(ctx)
+	}
+	for _, cancel := range p.cancels {
+		cancel()
+	}
+	p.browsers = map[string]context.Context{}
+	p.cancels = nil
+}
+
+// newTab opens a tab in Chrome with the user data directory, starting Chrome if it is not running.
+// The tab is closed when ctx is done or the returned function is called.
+func (p *BrowserPool) newTab(ctx context.Context, userDataDir string) (context.Context, context.CancelFunc, error) {
+	browserCtx, err := p.browser(userDataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tabCtx, cancel := chromedp.NewContext(browserCtx)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+
+	var once sync.Once
+	return tabCtx, func() {
+		once.Do(func() {
+			close(stop)
+			cancel()
+		})
+	}, nil
+}
+
+func (p *BrowserPool) browser(userDataDir string) (context.Context, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Chrome may have been closed by the user.
+	if ctx, ok := p.browsers[userDataDir]; ok && ctx.Err() == nil {
+		return ctx, nil
+	}
+
+	ctx, cancel := newChromeContext(p.ctx, userDataDir, false)
+	// Run without actions to start Chrome.
+	err := chromedp.Run(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	p.browsers[userDataDir] = ctx
+	p.cancels = append(p.cancels, cancel)
+	return ctx, nil
+}
@@ -0,0 +1,26 @@
+package idp
+
+import (
+	"context"
+)
+
+// Generic provides functionality of any IdP which supports IdP-initiated login
+//
+//	e.g. Keycloak, Google Workspace, OneLogin, AD FS and Shibboleth.
+type Generic struct {
+	startURL string
+	browser  browser
+}
+
+// NewGeneric returns Generic
+func NewGeneric(startURL string) Generic {
+	return Generic{
+		startURL: startURL,
+		browser:  newBrowser(),
+	}
+}
+
+// Authenticate opens the start URL of IdP-initiated login and fetches SAML response
+func (g *Generic) Authenticate(ctx context.Context, userDataDir string) (string, error) {
+	return g.browser.authenticate(ctx, userDataDir, g.startURL)
+}
@@ -0,0 +1,27 @@
+package idp
+
+import (
+	"context"
+	"time"
+)
+
+type headlessKey struct{}
+
+// headlessSettings is the timeouts of authentications given by WithHeadless
+type headlessSettings struct {
+	timeout            time.Duration
+	interactionTimeout time.Duration
+}
+
+// WithHeadless returns a context which makes authentications try headless Chrome first,
+// which succeeds without interaction while the session of the IdP in the user data directory is valid.
+// When the SAML response is not posted within timeout, Chrome is opened in a window to let the user interact with the IdP,
+// and the authentication gives up after interactionTimeout so that it does not block others forever.
+func WithHeadless(ctx context.Context, timeout time.Duration, interactionTimeout time.Duration) context.Context {
+	return context.WithValue(ctx, headlessKey{}, headlessSettings{timeout: timeout, interactionTimeout: interactionTimeout})
+}
+
+func headlessSettingsFrom(ctx context.Context) (headlessSettings, bool) {
+	settings, ok := ctx.Value(headlessKey{}).(headlessSettings)
+	return settings, ok
+}
@@ -0,0 +1,34 @@
+package idp
+
+import (
+	"context"
+	"strings"
+)
+
+// Okta provides functionality of Okta as IdP
+type Okta struct {
+	orgURL       string
+	appEmbedPath string
+	browser      browser
+}
+
+// NewOkta returns Okta
+//
+//	appEmbedPath is the path of the embed link of the AWS app, e.g. /home/amazon_aws/0oa1b2c3d4/272.
+func NewOkta(orgURL string, appEmbedPath string) Okta {
+	return Okta{
+		orgURL:       orgURL,
+		appEmbedPath: appEmbedPath,
+		browser:      newBrowser(),
+	}
+}
+
+// Authenticate opens the AWS app embed link of Okta and fetches SAML response
+func (o *Okta) Authenticate(ctx context.Context, userDataDir string) (string, error) {
+	return o.browser.authenticate(ctx, userDataDir, o.loginURL())
+}
+
+// loginURL returns the embed link of the AWS app regardless of slashes between the org URL and the path
+func (o *Okta) loginURL() string {
+	return strings.TrimSuffix(o.orgURL, "/") + "/" + strings.TrimPrefix(o.appEmbedPath, "/")
+}
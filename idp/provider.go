@@ -0,0 +1,37 @@
+package idp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cybozu/assam/aws"
+	"github.com/cybozu/assam/config"
+)
+
+// Provider is an identity provider which issues SAML responses for AWS
+type Provider interface {
+	// Authenticate lets a user sign in to the identity provider and returns the base64 encoded SAML response.
+	Authenticate(ctx context.Context, userDataDir string) (string, error)
+}
+
+// NewProvider returns the Provider selected by the config
+func NewProvider(cfg config.Config) (Provider, error) {
+	switch cfg.IDP {
+	case config.IDPAzure:
+		request, err := aws.CreateSAMLRequest(cfg.AppIDURI)
+		if err != nil {
+			return nil, err
+		}
+
+		azure := NewAzure(request, cfg.AzureTenantID)
+		return &azure, nil
+	case config.IDPOkta:
+		okta := NewOkta(cfg.OktaOrgURL, cfg.OktaAppEmbedPath)
+		return &okta, nil
+	case config.IDPGeneric:
+		generic := NewGeneric(cfg.SAMLStartURL)
+		return &generic, nil
+	default:
+		return nil, fmt.Errorf("unsupported identity provider: %s", cfg.IDP)
+	}
+}
@@ -0,0 +1,63 @@
+package idp
+
+import (
+	"testing"
+
+	"github.com/cybozu/assam/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProvider(t *testing.T) {
+	t.Run("returns Azure", func(t *testing.T) {
+		got, err := NewProvider(config.Config{IDP: config.IDPAzure, AppIDURI: "https://signin.aws.amazon.com/saml", AzureTenantID: "tenant"})
+
+		assert.NoError(t, err)
+		if azure, ok := got.(*Azure); assert.True(t, ok) {
+			assert.Equal(t, "tenant", azure.tenantID)
+			assert.NotEmpty(t, azure.samlRequest)
+		}
+	})
+
+	t.Run("returns Okta", func(t *testing.T) {
+		got, err := NewProvider(config.Config{IDP: config.IDPOkta, OktaOrgURL: "https://example.okta.com/", OktaAppEmbedPath: "/home/amazon_aws/0oa1b2c3d4/272"})
+
+		assert.NoError(t, err)
+		if okta, ok := got.(*Okta); assert.True(t, ok) {
+			assert.Equal(t, "https://example.okta.com/home/amazon_aws/0oa1b2c3d4/272", okta.loginURL())
+		}
+	})
+
+	t.Run("returns Generic", func(t *testing.T) {
+		got, err := NewProvider(config.Config{IDP: config.IDPGeneric, SAMLStartURL: "https://idp.example.com/start"})
+
+		assert.NoError(t, err)
+		if generic, ok := got.(*Generic); assert.True(t, ok) {
+			assert.Equal(t, "https://idp.example.com/start", generic.startURL)
+		}
+	})
+
+	t.Run("returns an error for unsupported IdP", func(t *testing.T) {
+		_, err := NewProvider(config.Config{IDP: "unknown"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestOktaLoginURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		orgURL       string
+		appEmbedPath string
+		want         string
+	}{
+		{name: "joins with a slash", orgURL: "https://example.okta.com", appEmbedPath: "/home/amazon_aws/0oa1b2c3d4/272", want: "https://example.okta.com/home/amazon_aws/0oa1b2c3d4/272"},
+		{name: "trims the trailing slash of the org URL", orgURL: "https://example.okta.com/", appEmbedPath: "/home/amazon_aws/0oa1b2c3d4/272", want: "https://example.okta.com/home/amazon_aws/0oa1b2c3d4/272"},
+		{name: "adds a slash to the path", orgURL: "https://example.okta.com", appEmbedPath: "home/amazon_aws/0oa1b2c3d4/272", want: "https://example.okta.com/home/amazon_aws/0oa1b2c3d4/272"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			okta := NewOkta(tt.orgURL, tt.appEmbedPath)
+			assert.Equal(t, tt.want, okta.loginURL())
+		})
+	}
+}
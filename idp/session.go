@@ -0,0 +1,84 @@
+package idp
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/storage"
+	"github.com/chromedp/chromedp"
+	"github.com/cybozu/assam/config"
+)
+
+// azureCookieDomains are domains of cookies which keep the session of Azure AD
+var azureCookieDomains = []string{"microsoftonline.com", "microsoft.com", "live.com"}
+
+// ClearSession deletes cookies of the IdP from the Chrome user data directory so that the next login asks for an account again
+func ClearSession(ctx context.Context, cfg config.Config) error {
+	domains, err := cookieDomains(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newChromeContext(ctx, cfg.ChromeUserDataDir, true)
+	defer cancel()
+
+	err = chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		cookies, err := storage.GetCookies().Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, cookie := range cookies {
+			if !matchCookieDomain(cookie.Domain, domains) {
+				continue
+			}
+
+			err = network.DeleteCookies(cookie.Name).WithDomain(cookie.Domain).WithPath(cookie.Path).Do(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+
+	// Shut down gracefully to ensure that user data is stored.
+	return chromedp.Cancel(ctx)
+}
+
+func cookieDomains(cfg config.Config) ([]string, error) {
+	switch cfg.IDP {
+	case config.IDPAzure:
+		return azureCookieDomains, nil
+	case config.IDPOkta:
+		return hostOf(cfg.OktaOrgURL)
+	case config.IDPGeneric:
+		return hostOf(cfg.SAMLStartURL)
+	default:
+		return nil, fmt.Errorf("unsupported identity provider: %s", cfg.IDP)
+	}
+}
+
+func hostOf(rawURL string) ([]string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return []string{u.Hostname()}, nil
+}
+
+// matchCookieDomain reports whether the cookie domain is one of the domains or their subdomains
+func matchCookieDomain(cookieDomain string, domains []string) bool {
+	cookieDomain = strings.TrimPrefix(cookieDomain, ".")
+	for _, d := range domains {
+		if cookieDomain == d || strings.HasSuffix(cookieDomain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package idp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchCookieDomain(t *testing.T) {
+	tests := []struct {
+		cookieDomain string
+		want         bool
+	}{
+		{cookieDomain: "login.microsoftonline.com", want: true},
+		{cookieDomain: ".login.microsoftonline.com", want: true},
+		{cookieDomain: "microsoftonline.com", want: true},
+		{cookieDomain: "notmicrosoftonline.com", want: false},
+		{cookieDomain: "example.com", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cookieDomain, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchCookieDomain(tt.cookieDomain, azureCookieDomains))
+		})
+	}
+}
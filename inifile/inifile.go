@@ -0,0 +1,106 @@
+// Package inifile updates INI files safely when several processes write them at the same time.
+package inifile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/ini.v1"
+)
+
+// SkipSave is returned by the update function of Update to leave the file as it is.
+var SkipSave = errors.New("skip save")
+
+// Update loads the INI file, applies update to it and saves it while holding the lock of the file.
+//
+// The file is replaced by renaming a temporary file so that readers never see a partially written file,
+// and the previous file is kept as <filename>.bak.
+// The permission of the file is perm, or stricter one if the existing file has it.
+// If the file is a symbolic link, its target is replaced and the link is kept.
+func Update(filename string, perm os.FileMode, update func(f *ini.File) error) error {
+	dir := filepath.Dir(filename)
+	err := os.MkdirAll(dir, os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	}
+
+	l, err := lock(filename + ".lock")
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	data, err := os.ReadFile(filename)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if exists {
+		info, err := os.Stat(filename)
+		if err != nil {
+			return err
+		}
+		perm &= info.Mode().Perm()
+	}
+
+	f := ini.Empty()
+	if exists {
+		f, err = ini.Load(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = update(f)
+	if errors.Is(err, SkipSave) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		err = writeFile(filename+".bak", perm, func(w *os.File) error {
+			_, err := w.Write(data)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeFile(filename, perm, func(w *os.File) error {
+		_, err := f.WriteTo(w)
+		return err
+	})
+}
+
+// writeFile writes the file by renaming a temporary file written by write
+func writeFile(filename string, perm os.FileMode, write func(w *os.File) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = tmp.Chmod(perm)
+	if err == nil {
+		err = write(tmp)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
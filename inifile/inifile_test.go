@@ -0,0 +1,117 @@
+package inifile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/ini.v1"
+)
+
+func TestUpdate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), ".aws", "credentials")
+
+	err := Update(file, os.FileMode(0600), func(f *ini.File) error {
+		f.Section("dev").Key("aws_access_key_id").SetValue("first")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoFileExists(t, file+".bak")
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(file)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	err = Update(file, os.FileMode(0600), func(f *ini.File) error {
+		f.Section("dev").Key("aws_access_key_id").SetValue("second")
+		return nil
+	})
+	assert.NoError(t, err)
+	assertKey(t, file, "dev", "aws_access_key_id", "second")
+	assertKey(t, file+".bak", "dev", "aws_access_key_id", "first")
+
+	err = Update(file, os.FileMode(0600), func(f *ini.File) error {
+		f.Section("dev").Key("aws_access_key_id").SetValue("skipped")
+		return SkipSave
+	})
+	assert.NoError(t, err)
+	assertKey(t, file, "dev", "aws_access_key_id", "second")
+
+	err = Update(file, os.FileMode(0600), func(f *ini.File) error {
+		return fmt.Errorf("failed")
+	})
+	assert.Error(t, err)
+	assertKey(t, file, "dev", "aws_access_key_id", "second")
+}
+
+func TestUpdateSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links needs a privilege on Windows")
+	}
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "credentials")
+	err := os.MkdirAll(filepath.Dir(target), os.FileMode(0755))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(target, []byte("[dev]\naws_access_key_id = first\n"), os.FileMode(0600))
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "credentials")
+	err = os.Symlink(target, link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Update(link, os.FileMode(0600), func(f *ini.File) error {
+		f.Section("dev").Key("aws_access_key_id").SetValue("second")
+		return nil
+	})
+	assert.NoError(t, err)
+	info, err := os.Lstat(link)
+	assert.NoError(t, err)
+	assert.Equal(t, os.ModeSymlink, info.Mode()&os.ModeSymlink)
+	assertKey(t, target, "dev", "aws_access_key_id", "second")
+	assertKey(t, target+".bak", "dev", "aws_access_key_id", "first")
+}
+
+func TestUpdateConcurrently(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := Update(file, os.FileMode(0644), func(f *ini.File) error {
+				f.Section(fmt.Sprintf("profile %d", i)).Key("region").SetValue("ap-northeast-1")
+				return nil
+			})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	f, err := ini.Load(file)
+	assert.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		assert.True(t, f.HasSection(fmt.Sprintf("profile %d", i)), "profile %d is lost", i)
+	}
+}
+
+func assertKey(t *testing.T, file, section, key, want string) {
+	t.Helper()
+
+	f, err := ini.Load(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, want, f.Section(section).Key(key).String())
+}
@@ -0,0 +1,30 @@
+//go:build !windows
+
+package inifile
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock acquires the exclusive lock of the file, waiting until other processes release it.
+// The lock is released by closing the returned file.
+func lock(filename string) (*os.File, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, os.FileMode(0600))
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
@@ -0,0 +1,29 @@
+package inifile
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const lockfileExclusiveLock = 0x00000002
+
+var procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+
+// lock acquires the exclusive lock of the file, waiting until other processes release it.
+// The lock is released by closing the returned file.
+func lock(filename string) (*os.File, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, os.FileMode(0600))
+	if err != nil {
+		return nil, err
+	}
+
+	var overlapped syscall.Overlapped
+	r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock, 0, 1, 0, uintptr(unsafe.Pointer(&overlapped)))
+	if r == 0 {
+		f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
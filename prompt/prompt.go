@@ -33,6 +33,27 @@ func NewPrompt() Prompt {
 	}
 }
 
+// NewStderrPrompt returns Prompt struct which writes to stderr so as not to mix with output to stdout
+func NewStderrPrompt() Prompt {
+	return Prompt{
+		writer:  os.Stderr,
+		scanner: bufio.NewScanner(os.Stdin),
+	}
+}
+
+// IsInteractive returns true when both stdin and stderr are terminals
+func IsInteractive() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stderr)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 // AskString asks query and returns input string
 func (p *Prompt) AskString(query string, options *Options) (string, error) {
 	if options == nil {
@@ -109,6 +130,154 @@ func (p *Prompt) AskInt(query string, options *Options) (int, error) {
 	}
 }
 
+// AskBool asks yes/no query and returns input bool
+func (p *Prompt) AskBool(query string, options *Options) (bool, error) {
+	if options == nil {
+		options = &Options{}
+	}
+
+	for {
+		// prompt
+		err := p.printPrompt(query, options)
+		if err != nil {
+			return false, err
+		}
+
+		// scan
+		val, err := p.scanString()
+		if err != nil {
+			return false, err
+		}
+		if val == "" {
+			return parseBool(options.Default)
+		}
+
+		// validate
+		ret, err := parseBool(val)
+		if err == nil && options.ValidateFunc != nil {
+			err = options.ValidateFunc(val)
+		}
+		if err != nil {
+			_, err := fmt.Fprintln(p.writer, err.Error())
+			if err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		return ret, nil
+	}
+}
+
+// AskSelect asks to select one of choices and returns the index of the selected choice.
+// Input is either the number of a choice or a text to filter choices.
+func (p *Prompt) AskSelect(query string, choices []string, options *Options) (int, error) {
+	if options == nil {
+		options = &Options{}
+	}
+
+	candidates := allIndexes(choices)
+	for {
+		// prompt
+		for _, i := range candidates {
+			_, err := fmt.Fprintf(p.writer, "%3d) %s\n", i+1, choices[i])
+			if err != nil {
+				return 0, err
+			}
+		}
+		err := p.printPrompt(query, options)
+		if err != nil {
+			return 0, err
+		}
+
+		// scan
+		val, err := p.scanString()
+		if err != nil {
+			return 0, err
+		}
+		if val == "" {
+			if options.Default != "" {
+				return parseChoice(options.Default, allIndexes(choices))
+			}
+			if len(candidates) == len(choices) {
+				return 0, fmt.Errorf("no choice is selected")
+			}
+			// Clear the filter.
+			candidates = allIndexes(choices)
+			continue
+		}
+
+		// select by number
+		if _, err := strconv.Atoi(val); err == nil {
+			i, err := parseChoice(val, candidates)
+			if err != nil {
+				_, err := fmt.Fprintln(p.writer, err.Error())
+				if err != nil {
+					return 0, err
+				}
+				continue
+			}
+			return i, nil
+		}
+
+		// filter by text
+		filtered := filterChoices(choices, candidates, val)
+		switch len(filtered) {
+		case 0:
+			_, err := fmt.Fprintf(p.writer, "no choice matches: %s\n", val)
+			if err != nil {
+				return 0, err
+			}
+		case 1:
+			return filtered[0], nil
+		default:
+			candidates = filtered
+		}
+	}
+}
+
+func allIndexes(choices []string) []int {
+	indexes := make([]int, len(choices))
+	for i := range choices {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+func parseChoice(val string, candidates []int) (int, error) {
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, err
+	}
+	for _, i := range candidates {
+		if i+1 == n {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid choice: %s", val)
+}
+
+func filterChoices(choices []string, candidates []int, text string) []int {
+	var filtered []int
+	for _, i := range candidates {
+		if strings.Contains(strings.ToLower(choices[i]), strings.ToLower(text)) {
+			filtered = append(filtered, i)
+		}
+	}
+	return filtered
+}
+
+func parseBool(val string) (bool, error) {
+	switch strings.ToLower(val) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	default:
+		return false, fmt.Errorf("please answer y or n: %s", val)
+	}
+}
+
 func (p *Prompt) printPrompt(query string, options *Options) error {
 	if options.Default != "" {
 		_, err := fmt.Fprintf(p.writer, "%s (Default: %s): ", query, options.Default)
@@ -229,3 +229,247 @@ func TestPrompt_AskInt(t *testing.T) {
 		})
 	}
 }
+
+func TestPrompt_AskBool(t *testing.T) {
+	type fields struct {
+		writer  io.Writer
+		scanner *bufio.Scanner
+	}
+	type args struct {
+		query   string
+		options *Options
+	}
+	type want struct {
+		ret    bool
+		prompt string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    want
+		wantErr bool
+	}{
+		{
+			name: "returns true when input is yes",
+			fields: fields{
+				writer:  new(bytes.Buffer),
+				scanner: bufio.NewScanner(bytes.NewBufferString("Yes")),
+			},
+			args: args{
+				query: "Please answer",
+			},
+			want: want{
+				ret:    true,
+				prompt: "Please answer: ",
+			},
+		},
+		{
+			name: "returns false when input is n",
+			fields: fields{
+				writer:  new(bytes.Buffer),
+				scanner: bufio.NewScanner(bytes.NewBufferString(" n ")),
+			},
+			args: args{
+				query: "Please answer",
+			},
+			want: want{
+				ret:    false,
+				prompt: "Please answer: ",
+			},
+		},
+		{
+			name: "returns default value when input is empty",
+			fields: fields{
+				writer:  new(bytes.Buffer),
+				scanner: bufio.NewScanner(bytes.NewBufferString("")),
+			},
+			args: args{
+				query: "Please answer",
+				options: &Options{
+					Default: "y",
+				},
+			},
+			want: want{
+				ret:    true,
+				prompt: "Please answer (Default: y): ",
+			},
+		},
+		{
+			name: "asks again when input is neither yes nor no",
+			fields: fields{
+				writer:  new(bytes.Buffer),
+				scanner: bufio.NewScanner(bytes.NewBufferString("maybe\nno")),
+			},
+			args: args{
+				query: "Please answer",
+			},
+			want: want{
+				ret:    false,
+				prompt: "Please answer: please answer y or n: maybe\nPlease answer: ",
+			},
+		},
+		{
+			name: "returns an error when input and default value are empty",
+			fields: fields{
+				writer:  new(bytes.Buffer),
+				scanner: bufio.NewScanner(bytes.NewBufferString("")),
+			},
+			args: args{
+				query: "Please answer",
+			},
+			want: want{
+				prompt: "Please answer: ",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Prompt{
+				writer:  tt.fields.writer,
+				scanner: tt.fields.scanner,
+			}
+			got, err := p.AskBool(tt.args.query, tt.args.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Prompt.AskBool() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want.ret {
+				t.Errorf("Prompt.AskBool() = '%v', want '%v'", got, tt.want.ret)
+			}
+			actualPrompt := tt.fields.writer.(*bytes.Buffer).Bytes()
+			expectedPrompt := []byte(tt.want.prompt)
+			if !bytes.Equal(actualPrompt, expectedPrompt) {
+				t.Errorf("Prompt of Prompt.AskBool() = '%s', want '%s'", actualPrompt, expectedPrompt)
+			}
+		})
+	}
+}
+
+func TestPrompt_AskSelect(t *testing.T) {
+	choices := []string{"012345678901 / Admin", "012345678901 / ReadOnly", "123456789012 / Admin"}
+
+	type fields struct {
+		writer  io.Writer
+		scanner *bufio.Scanner
+	}
+	type args struct {
+		query   string
+		choices []string
+		options *Options
+	}
+	type want struct {
+		ret    int
+		prompt string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    want
+		wantErr bool
+	}{
+		{
+			name: "returns index of the selected number",
+			fields: fields{
+				writer:  new(bytes.Buffer),
+				scanner: bufio.NewScanner(bytes.NewBufferString("2")),
+			},
+			args: args{
+				query:   "Select",
+				choices: choices,
+			},
+			want: want{
+				ret:    1,
+				prompt: "  1) 012345678901 / Admin\n  2) 012345678901 / ReadOnly\n  3) 123456789012 / Admin\nSelect: ",
+			},
+		},
+		{
+			name: "returns index of the only choice matching the filter",
+			fields: fields{
+				writer:  new(bytes.Buffer),
+				scanner: bufio.NewScanner(bytes.NewBufferString("readonly")),
+			},
+			args: args{
+				query:   "Select",
+				choices: choices,
+			},
+			want: want{
+				ret:    1,
+				prompt: "  1) 012345678901 / Admin\n  2) 012345678901 / ReadOnly\n  3) 123456789012 / Admin\nSelect: ",
+			},
+		},
+		{
+			name: "narrows choices by the filter",
+			fields: fields{
+				writer:  new(bytes.Buffer),
+				scanner: bufio.NewScanner(bytes.NewBufferString("admin\n2\n3")),
+			},
+			args: args{
+				query:   "Select",
+				choices: choices,
+			},
+			want: want{
+				ret: 2,
+				prompt: "  1) 012345678901 / Admin\n  2) 012345678901 / ReadOnly\n  3) 123456789012 / Admin\nSelect: " +
+					"  1) 012345678901 / Admin\n  3) 123456789012 / Admin\nSelect: invalid choice: 2\n" +
+					"  1) 012345678901 / Admin\n  3) 123456789012 / Admin\nSelect: ",
+			},
+		},
+		{
+			name: "returns default value when input is empty",
+			fields: fields{
+				writer:  new(bytes.Buffer),
+				scanner: bufio.NewScanner(bytes.NewBufferString("")),
+			},
+			args: args{
+				query:   "Select",
+				choices: choices,
+				options: &Options{
+					Default: "3",
+				},
+			},
+			want: want{
+				ret:    2,
+				prompt: "  1) 012345678901 / Admin\n  2) 012345678901 / ReadOnly\n  3) 123456789012 / Admin\nSelect (Default: 3): ",
+			},
+		},
+		{
+			name: "returns an error when input is empty without default value",
+			fields: fields{
+				writer:  new(bytes.Buffer),
+				scanner: bufio.NewScanner(bytes.NewBufferString("")),
+			},
+			args: args{
+				query:   "Select",
+				choices: choices,
+			},
+			want: want{
+				prompt: "  1) 012345678901 / Admin\n  2) 012345678901 / ReadOnly\n  3) 123456789012 / Admin\nSelect: ",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Prompt{
+				writer:  tt.fields.writer,
+				scanner: tt.fields.scanner,
+			}
+			got, err := p.AskSelect(tt.args.query, tt.args.choices, tt.args.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Prompt.AskSelect() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want.ret {
+				t.Errorf("Prompt.AskSelect() = '%v', want '%v'", got, tt.want.ret)
+			}
+			actualPrompt := tt.fields.writer.(*bytes.Buffer).Bytes()
+			expectedPrompt := []byte(tt.want.prompt)
+			if !bytes.Equal(actualPrompt, expectedPrompt) {
+				t.Errorf("Prompt of Prompt.AskSelect() = '%s', want '%s'", actualPrompt, expectedPrompt)
+			}
+		})
+	}
+}